@@ -0,0 +1,169 @@
+// Package auth adds sign-in to Via applications: password, reverse-proxy
+// header and OpenID Connect authentication, page guards, and sign-out that
+// closes every live tab of the session.
+//
+// Example:
+//
+//	users := auth.NewUserStore()
+//	users.Add("alice", "s3cret", via.User{Name: "Alice"})
+//	a := auth.New(v, auth.Config{})
+//
+//	v.Page("/login", func(c *via.Context) {
+//		name, pass := c.Signal(""), c.Signal("")
+//		login := c.Action(func() {
+//			if err := a.LoginWithPassword(c, users, name.String(), pass.String()); err != nil {
+//				c.Flash("error", "Invalid username or password")
+//				c.Redirect("/login")
+//			}
+//		})
+//		(...)
+//	})
+//
+//	v.Page("/", homePage, a.RequireAuth())
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ryanhamamura/via"
+)
+
+const nextSessionKey = "via.auth.next"
+
+// ErrInvalidCredentials is returned when a username or password is wrong.
+var ErrInvalidCredentials = errors.New("auth: invalid credentials")
+
+// Authenticator identifies the user of a request from the request itself,
+// e.g. headers set by an authenticating proxy. It returns a nil user and nil
+// error if the request carries no identity.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*via.User, error)
+}
+
+// PasswordVerifier checks a username and password and returns the matching
+// user, or an error wrapping ErrInvalidCredentials.
+type PasswordVerifier interface {
+	VerifyPassword(username, password string) (*via.User, error)
+}
+
+// Config configures an Auth.
+type Config struct {
+	// LoginPath is where RequireAuth sends anonymous visitors and Logout
+	// sends signed-out ones. Defaults to "/login".
+	LoginPath string
+
+	// HomePath is where Login sends users who did not come from a guarded
+	// page. Defaults to "/".
+	HomePath string
+
+	// Authenticators identify users from the request. RequireAuth signs in
+	// the user they return, so no login page is needed, e.g. behind an
+	// authenticating proxy with HeaderAuth.
+	Authenticators []Authenticator
+}
+
+// Auth signs users in and out of their Via session and guards pages.
+type Auth struct {
+	v   *via.V
+	cfg Config
+}
+
+// New creates an Auth for v. Sessions must be enabled on v, which they are
+// by default.
+func New(v *via.V, cfg Config) *Auth {
+	if cfg.LoginPath == "" {
+		cfg.LoginPath = "/login"
+	}
+	if cfg.HomePath == "" {
+		cfg.HomePath = "/"
+	}
+	return &Auth{v: v, cfg: cfg}
+}
+
+// RequireAuth returns a guard that lets signed-in users through and
+// redirects anyone else to the login page. The guarded URL is remembered so
+// Login can send the user back to it.
+//
+// Example:
+//
+//	v.Page("/settings", settingsPage, a.RequireAuth())
+//	admin := v.Group("/admin", a.RequireAuth())
+func (a *Auth) RequireAuth() via.Guard {
+	return func(w http.ResponseWriter, r *http.Request) bool {
+		s := a.v.Session(r)
+		current := s.User()
+		for _, au := range a.cfg.Authenticators {
+			u, err := au.Authenticate(r)
+			if err != nil || u == nil {
+				continue
+			}
+			if current != nil && current.ID == u.ID {
+				return true
+			}
+			if err := signIn(s, u); err != nil {
+				http.Error(w, "sign in failed", http.StatusInternalServerError)
+				return false
+			}
+			return true
+		}
+		if current != nil {
+			return true
+		}
+		s.Set(nextSessionKey, r.URL.RequestURI())
+		http.Redirect(w, r, a.cfg.LoginPath, http.StatusSeeOther)
+		return false
+	}
+}
+
+// Login signs u in to the session of c and redirects the browser to the
+// page that required authentication, or to Config.HomePath. The session
+// token is renewed first to prevent session fixation, which closes other
+// tabs still bound to the old token.
+func (a *Auth) Login(c *via.Context, u *via.User) error {
+	s := c.Session()
+	if err := signIn(s, u); err != nil {
+		return err
+	}
+	c.Redirect(a.next(s))
+	return nil
+}
+
+// LoginWithPassword verifies username and password with pv and signs the
+// user in with Login.
+func (a *Auth) LoginWithPassword(c *via.Context, pv PasswordVerifier, username, password string) error {
+	u, err := pv.VerifyPassword(username, password)
+	if err != nil {
+		return err
+	}
+	return a.Login(c, u)
+}
+
+// Logout destroys the session of c, which closes the live contexts of the
+// session in every tab and on every instance, and redirects the browser to
+// the login page.
+func (a *Auth) Logout(c *via.Context) error {
+	if err := c.Session().Destroy(); err != nil {
+		return err
+	}
+	c.Redirect(a.cfg.LoginPath)
+	return nil
+}
+
+// next pops the URL remembered by RequireAuth. Only local paths are
+// accepted, so the value cannot redirect users to another site.
+func (a *Auth) next(s *via.Session) string {
+	next := s.PopString(nextSessionKey)
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return a.cfg.HomePath
+	}
+	return next
+}
+
+func signIn(s *via.Session, u *via.User) error {
+	if err := s.RenewToken(); err != nil {
+		return err
+	}
+	return s.SetUser(u)
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/ryanhamamura/via"
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ctxRe    = regexp.MustCompile(`&#39;via-ctx&#39;:&#39;([^&]+)&#39;,&#39;via-csrf&#39;:&#39;([^&]+)&#39;`)
+	actionRe = regexp.MustCompile(`/_action/([0-9a-f]+)`)
+	dataRe   = regexp.MustCompile(`data-(\w+)="([0-9a-f]+)"`)
+)
+
+// testApp serves v behind the session middleware to a cookie-keeping client.
+type testApp struct {
+	t      *testing.T
+	v      *via.V
+	srv    *httptest.Server
+	client *http.Client
+}
+
+func newTestApp(t *testing.T, v *via.V, sm *scs.SessionManager) *testApp {
+	srv := httptest.NewServer(sm.LoadAndSave(v.HTTPServeMux()))
+	t.Cleanup(srv.Close)
+	jar, _ := cookiejar.New(nil)
+	return &testApp{t: t, v: v, srv: srv, client: &http.Client{Jar: jar}}
+}
+
+func (a *testApp) get(path string) (*http.Response, string) {
+	a.t.Helper()
+	resp, err := a.client.Get(a.srv.URL + path)
+	require.NoError(a.t, err)
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, string(b)
+}
+
+func (a *testApp) cookie(name string) string {
+	u, _ := url.Parse(a.srv.URL)
+	for _, c := range a.client.Jar.Cookies(u) {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// action calls the nth action rendered in page with the given signals.
+func (a *testApp) action(page string, n int, sigs map[string]any) {
+	a.t.Helper()
+	m := ctxRe.FindStringSubmatch(page)
+	require.NotNil(a.t, m, "page has no via context")
+	actions := actionRe.FindAllStringSubmatch(page, -1)
+	require.Greater(a.t, len(actions), n)
+	if sigs == nil {
+		sigs = map[string]any{}
+	}
+	sigs["via-ctx"], sigs["via-csrf"] = m[1], m[2]
+	b, _ := json.Marshal(sigs)
+	resp, _ := a.get("/_action/" + actions[n][1] + "?datastar=" + url.QueryEscape(string(b)))
+	require.Equal(a.t, http.StatusOK, resp.StatusCode)
+}
+
+// dataAttrs maps the data-* attributes of page to their values.
+func dataAttrs(page string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range dataRe.FindAllStringSubmatch(page, -1) {
+		attrs[m[1]] = m[2]
+	}
+	return attrs
+}
+
+func TestPasswordLoginGuardAndLogout(t *testing.T) {
+	users := NewUserStore()
+	require.NoError(t, users.Add("alice", "s3cret", via.User{Name: "Alice"}))
+
+	sm := via.NewMemorySessionManager()
+	v := via.New()
+	v.Config(via.Options{SessionManager: sm})
+	a := New(v, Config{})
+
+	var loginErr error
+	v.Page("/login", func(c *via.Context) {
+		name, pass := c.Signal(""), c.Signal("")
+		login := c.Action(func() {
+			loginErr = a.LoginWithPassword(c, users, name.String(), pass.String())
+		})
+		c.View(func() h.H {
+			return h.Div(name.Bind(), pass.Bind(), h.Button(login.OnClick()),
+				h.Span(h.Data("name", name.ID())), h.Span(h.Data("pass", pass.ID())))
+		})
+	})
+	v.Page("/orders/{id}", func(c *via.Context) {
+		logout := c.Action(func() { _ = a.Logout(c) })
+		c.View(func() h.H {
+			return h.Div(h.Textf("orders of %s", c.User().Name), h.Button(logout.OnClick()))
+		})
+	}, a.RequireAuth())
+	app := newTestApp(t, v, sm)
+
+	// anonymous visitors are sent to the login page
+	resp, page := app.get("/orders/7")
+	assert.Equal(t, "/login", resp.Request.URL.Path)
+
+	ids := dataAttrs(page)
+	app.action(page, 0, map[string]any{ids["name"]: "alice", ids["pass"]: "wrong"})
+	assert.ErrorIs(t, loginErr, ErrInvalidCredentials)
+	resp, _ = app.get("/orders/7")
+	assert.Equal(t, "/login", resp.Request.URL.Path)
+
+	_, page = app.get("/login")
+	ids = dataAttrs(page)
+	app.action(page, 0, map[string]any{ids["name"]: "alice", ids["pass"]: "s3cret"})
+	require.NoError(t, loginErr)
+
+	resp, page = app.get("/orders/7")
+	assert.Equal(t, "/orders/7", resp.Request.URL.Path)
+	assert.Contains(t, page, "orders of Alice")
+
+	// a second tab of the same session is closed by logout in the first
+	app.get("/orders/8")
+	token := app.cookie(sm.Cookie.Name)
+	assert.Len(t, v.ContextsForSession(token), 3) // login, orders/7, orders/8
+	app.action(page, 0, nil)
+	left := v.ContextsForSession(token)
+	require.Len(t, left, 1) // the tab that logged out, until it follows the redirect
+	assert.Equal(t, ctxRe.FindStringSubmatch(page)[1], left[0].ID())
+	resp, _ = app.get("/orders/7")
+	assert.Equal(t, "/login", resp.Request.URL.Path)
+}
+
+func TestRequireAuth_HeaderAuthenticator(t *testing.T) {
+	sm := via.NewMemorySessionManager()
+	v := via.New()
+	v.Config(via.Options{SessionManager: sm})
+	a := New(v, Config{Authenticators: []Authenticator{HeaderAuth{
+		NameHeader:     "X-Forwarded-Name",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	}}})
+	v.Page("/", func(c *via.Context) {
+		c.View(func() h.H { return h.Textf("hello %s <%s>", c.User().Name, c.User().Email) })
+	}, a.RequireAuth())
+	app := newTestApp(t, v, sm)
+
+	req, _ := http.NewRequest("GET", app.srv.URL+"/", nil)
+	req.Header.Set("X-Forwarded-User", "u42")
+	req.Header.Set("X-Forwarded-Email", "bob@example.com")
+	req.Header.Set("X-Forwarded-Name", "Bob")
+	resp, err := app.client.Do(req)
+	require.NoError(t, err)
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(b), "hello Bob &lt;bob@example.com&gt;")
+
+	// the session remembers the user once signed in
+	_, page := app.get("/")
+	assert.Contains(t, page, "hello Bob")
+}
+
+func TestNext_OnlyLocalPaths(t *testing.T) {
+	sm := via.NewMemorySessionManager()
+	v := via.New()
+	v.Config(via.Options{SessionManager: sm})
+	a := New(v, Config{HomePath: "/home"})
+
+	for next, want := range map[string]string{
+		"/orders/7?tab=2":     "/orders/7?tab=2",
+		"":                    "/home",
+		"https://evil.test/":  "/home",
+		"//evil.test/":        "/home",
+		"/\\evil.test/":       "/home",
+		"javascript:alert(1)": "/home",
+	} {
+		ctx, err := sm.Load(t.Context(), "")
+		require.NoError(t, err)
+		r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+		s := v.Session(r)
+		s.Set(nextSessionKey, next)
+		assert.Equal(t, want, a.next(s), next)
+	}
+}
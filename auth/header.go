@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"cmp"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/ryanhamamura/via"
+)
+
+// ErrUntrustedProxy is returned by HeaderAuth for requests that did not come
+// from a trusted proxy.
+var ErrUntrustedProxy = errors.New("auth: request not from a trusted proxy")
+
+// HeaderAuth is an Authenticator for apps behind an authenticating reverse
+// proxy, such as oauth2-proxy or Tailscale, that passes the user in request
+// headers.
+//
+// Example:
+//
+//	a := auth.New(v, auth.Config{
+//		Authenticators: []auth.Authenticator{auth.HeaderAuth{
+//			TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//		}},
+//	})
+type HeaderAuth struct {
+	// UserHeader holds the user ID. Defaults to "X-Forwarded-User".
+	UserHeader string
+	// EmailHeader holds the user email. Defaults to "X-Forwarded-Email".
+	EmailHeader string
+	// NameHeader holds the display name. Optional.
+	NameHeader string
+	// RolesHeader holds comma separated roles, e.g. "X-Forwarded-Groups".
+	// Optional.
+	RolesHeader string
+	// TrustedProxies restricts header auth to requests from these networks.
+	// Empty rejects every request unless TrustAll is set.
+	TrustedProxies []netip.Prefix
+	// TrustAll accepts the headers from every peer. It is only safe if the
+	// proxy is the only way to reach the app, since any client can send
+	// the headers.
+	TrustAll bool
+}
+
+// Authenticate returns the user named in the request headers.
+func (h HeaderAuth) Authenticate(r *http.Request) (*via.User, error) {
+	id := r.Header.Get(cmp.Or(h.UserHeader, "X-Forwarded-User"))
+	if id == "" {
+		return nil, nil
+	}
+	if !h.trusted(r) {
+		return nil, ErrUntrustedProxy
+	}
+	u := &via.User{
+		ID:    id,
+		Email: r.Header.Get(cmp.Or(h.EmailHeader, "X-Forwarded-Email")),
+	}
+	if h.NameHeader != "" {
+		u.Name = r.Header.Get(h.NameHeader)
+	}
+	if h.RolesHeader != "" {
+		for _, role := range strings.Split(r.Header.Get(h.RolesHeader), ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				u.Roles = append(u.Roles, role)
+			}
+		}
+	}
+	return u, nil
+}
+
+func (h HeaderAuth) trusted(r *http.Request) bool {
+	if h.TrustAll {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	return slices.ContainsFunc(h.TrustedProxies, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderAuth(t *testing.T) {
+	ha := HeaderAuth{
+		RolesHeader:    "X-Forwarded-Groups",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.1.2.3:4567"
+	u, err := ha.Authenticate(r)
+	require.NoError(t, err)
+	assert.Nil(t, u, "no user header")
+
+	r.Header.Set("X-Forwarded-User", "alice")
+	r.Header.Set("X-Forwarded-Email", "alice@example.com")
+	r.Header.Set("X-Forwarded-Groups", "admin, staff,,")
+	u, err = ha.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.ID)
+	assert.Equal(t, "alice@example.com", u.Email)
+	assert.Equal(t, []string{"admin", "staff"}, u.Roles)
+
+	r.RemoteAddr = "192.0.2.1:4567"
+	u, err = ha.Authenticate(r)
+	assert.ErrorIs(t, err, ErrUntrustedProxy)
+	assert.Nil(t, u)
+
+	// without trusted proxies header auth is off unless TrustAll is set
+	r.Header.Set("X-Forwarded-User", "mallory")
+	_, err = HeaderAuth{}.Authenticate(r)
+	assert.ErrorIs(t, err, ErrUntrustedProxy)
+	u, err = HeaderAuth{TrustAll: true}.Authenticate(r)
+	require.NoError(t, err)
+	assert.Equal(t, "mallory", u.ID)
+}
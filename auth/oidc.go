@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/ryanhamamura/via"
+	"golang.org/x/oauth2"
+)
+
+const oidcSessionKey = "via.auth.oidc"
+
+// OIDCConfig configures sign-in with an OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the provider URL, e.g. "https://accounts.google.com".
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute callback URL registered with the
+	// provider. MountOIDC serves its path.
+	RedirectURL string
+	// Scopes default to openid, profile and email.
+	Scopes []string
+	// RolesClaim names an ID token claim listing the user's roles, e.g.
+	// "groups". Optional.
+	RolesClaim string
+}
+
+// OIDC signs users in with an OpenID Connect provider using the
+// authorization code flow with PKCE.
+type OIDC struct {
+	cfg      OIDCConfig
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oidcFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// NewOIDC discovers the provider configuration at cfg.Issuer.
+func NewOIDC(ctx context.Context, cfg OIDCConfig) (*OIDC, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("auth: discover oidc provider: %w", err)
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	return &OIDC{
+		cfg: cfg,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+// MountOIDC serves the sign-in flow of o: startPath redirects the browser to
+// the provider, and the path of the configured RedirectURL signs the
+// returning user in and sends them on like Login. Link to startPath from the
+// login page, or use it as Config.LoginPath to skip the login page.
+func (a *Auth) MountOIDC(o *OIDC, startPath string) error {
+	callback, err := url.Parse(o.cfg.RedirectURL)
+	if err != nil {
+		return fmt.Errorf("auth: parse oidc redirect url: %w", err)
+	}
+	mux := a.v.HTTPServeMux()
+	mux.HandleFunc("GET "+startPath, func(w http.ResponseWriter, r *http.Request) {
+		flow := oidcFlow{State: randToken(), Nonce: randToken(), Verifier: oauth2.GenerateVerifier()}
+		if err := via.SessionSet(a.v.Session(r), oidcSessionKey, flow); err != nil {
+			http.Error(w, "sign in failed", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, o.oauth.AuthCodeURL(flow.State,
+			oidc.Nonce(flow.Nonce), oauth2.S256ChallengeOption(flow.Verifier)), http.StatusFound)
+	})
+	mux.HandleFunc("GET "+callback.Path, func(w http.ResponseWriter, r *http.Request) {
+		s := a.v.Session(r)
+		flow, ok := via.SessionPop[oidcFlow](s, oidcSessionKey)
+		q := r.URL.Query()
+		if !ok || q.Get("state") != flow.State {
+			http.Error(w, "invalid sign in state", http.StatusBadRequest)
+			return
+		}
+		if q.Get("error") != "" {
+			http.Error(w, "sign in denied", http.StatusUnauthorized)
+			return
+		}
+		u, err := o.exchange(r.Context(), q.Get("code"), flow)
+		if err != nil {
+			http.Error(w, "sign in failed", http.StatusUnauthorized)
+			return
+		}
+		if err := signIn(s, u); err != nil {
+			http.Error(w, "sign in failed", http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, a.next(s), http.StatusSeeOther)
+	})
+	return nil
+}
+
+// exchange redeems code for an ID token and returns the user it names.
+func (o *OIDC) exchange(ctx context.Context, code string, flow oidcFlow) (*via.User, error) {
+	tok, err := o.oauth.Exchange(ctx, code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("auth: exchange oidc code: %w", err)
+	}
+	raw, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("auth: oidc token response without id_token")
+	}
+	idt, err := o.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, fmt.Errorf("auth: verify oidc id token: %w", err)
+	}
+	if idt.Nonce != flow.Nonce {
+		return nil, fmt.Errorf("auth: oidc nonce mismatch")
+	}
+	var claims map[string]any
+	if err := idt.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("auth: decode oidc claims: %w", err)
+	}
+	u := &via.User{ID: idt.Subject}
+	u.Name, _ = claims["name"].(string)
+	u.Email, _ = claims["email"].(string)
+	if o.cfg.RolesClaim != "" {
+		switch roles := claims[o.cfg.RolesClaim].(type) {
+		case []any:
+			for _, r := range roles {
+				if s, ok := r.(string); ok {
+					u.Roles = append(u.Roles, s)
+				}
+			}
+		case string:
+			u.Roles = []string{roles}
+		}
+	}
+	return u, nil
+}
+
+func randToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/ryanhamamura/via"
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOIDC is a minimal OpenID provider that signs in a fixed user without
+// asking and issues RS256 ID tokens.
+type mockOIDC struct {
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+
+	mu    sync.Mutex
+	codes map[string]string // code -> nonce
+}
+
+func newMockOIDC(t *testing.T, claims map[string]any) *mockOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockOIDC{key: key, claims: claims, codes: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                m.srv.URL,
+			"authorization_endpoint":                m.srv.URL + "/authorize",
+			"token_endpoint":                        m.srv.URL + "/token",
+			"jwks_uri":                              m.srv.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		code := randToken()
+		m.mu.Lock()
+		m.codes[code] = q.Get("nonce")
+		m.mu.Unlock()
+		cb, _ := url.Parse(q.Get("redirect_uri"))
+		cb.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, cb.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.mu.Lock()
+		nonce, ok := m.codes[r.Form.Get("code")]
+		delete(m.codes, r.Form.Get("code"))
+		m.mu.Unlock()
+		if !ok || r.Form.Get("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		clientID, _, ok := r.BasicAuth()
+		if !ok {
+			clientID = r.Form.Get("client_id")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     m.idToken(t, clientID, nonce),
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &m.key.PublicKey, KeyID: "k1", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockOIDC) idToken(t *testing.T, clientID, nonce string) string {
+	sig, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: m.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "k1"))
+	require.NoError(t, err)
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   m.srv.URL,
+		"aud":   clientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": nonce,
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	raw, err := jwt.Signed(sig).Claims(claims).Serialize()
+	require.NoError(t, err)
+	return raw
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	provider := newMockOIDC(t, map[string]any{
+		"sub":    "u-123",
+		"name":   "Alice",
+		"email":  "alice@example.com",
+		"groups": []string{"admin", "staff"},
+	})
+
+	sm := via.NewMemorySessionManager()
+	v := via.New()
+	v.Config(via.Options{SessionManager: sm})
+	a := New(v, Config{LoginPath: "/auth/start"})
+	v.Page("/orders", func(c *via.Context) {
+		c.View(func() h.H {
+			u := c.User()
+			return h.Textf("%s %s %s %v", u.ID, u.Name, u.Email, u.HasRole("admin"))
+		})
+	}, a.RequireAuth())
+	app := newTestApp(t, v, sm)
+
+	o, err := NewOIDC(t.Context(), OIDCConfig{
+		Issuer:      provider.srv.URL,
+		ClientID:    "via-app",
+		RedirectURL: app.srv.URL + "/auth/callback",
+		RolesClaim:  "groups",
+	})
+	require.NoError(t, err)
+	require.NoError(t, a.MountOIDC(o, "/auth/start"))
+
+	// guard -> start -> provider -> callback -> back to the guarded page
+	resp, page := app.get("/orders")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/orders", resp.Request.URL.Path)
+	assert.Contains(t, page, "u-123 Alice alice@example.com true")
+
+	// a callback without a started flow is rejected
+	resp, _ = app.get("/auth/callback?code=x&state=y")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ryanhamamura/via"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcryptCost is the work factor of new hashes. Tests lower it.
+var bcryptCost = bcrypt.DefaultCost
+
+// dummyHash is compared against when a username is unknown, so a failed
+// login takes as long whether or not the user exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("via-dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword returns the bcrypt hash of password, e.g. to store in a
+// config file or database and load with UserStore.AddHash.
+func HashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+}
+
+// UserStore is an in-memory PasswordVerifier holding bcrypt password hashes.
+type UserStore struct {
+	mu    sync.RWMutex
+	users map[string]storedUser
+}
+
+type storedUser struct {
+	user via.User
+	hash []byte
+}
+
+// NewUserStore creates an empty user store.
+func NewUserStore() *UserStore {
+	return &UserStore{users: make(map[string]storedUser)}
+}
+
+// Add hashes password and stores u under username. An empty u.ID defaults
+// to username.
+func (s *UserStore) Add(username, password string, u via.User) error {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("auth: hash password: %w", err)
+	}
+	s.AddHash(username, hash, u)
+	return nil
+}
+
+// AddHash stores u under username with a bcrypt hash made by HashPassword.
+// An empty u.ID defaults to username.
+func (s *UserStore) AddHash(username string, hash []byte, u via.User) {
+	if u.ID == "" {
+		u.ID = username
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = storedUser{user: u, hash: hash}
+}
+
+// Remove deletes the user stored under username.
+func (s *UserStore) Remove(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, username)
+}
+
+// VerifyPassword returns the user stored under username if password
+// matches its hash.
+func (s *UserStore) VerifyPassword(username, password string) (*via.User, error) {
+	s.mu.RLock()
+	su, ok := s.users[username]
+	s.mu.RUnlock()
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword(su.hash, []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	u := su.user
+	return &u, nil
+}
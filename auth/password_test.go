@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/ryanhamamura/via"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	bcryptCost = bcrypt.MinCost
+}
+
+func TestUserStore(t *testing.T) {
+	s := NewUserStore()
+	require.NoError(t, s.Add("alice", "s3cret", via.User{Name: "Alice", Roles: []string{"admin"}}))
+	hash, err := HashPassword("hunter2")
+	require.NoError(t, err)
+	s.AddHash("bob", hash, via.User{ID: "u2"})
+
+	u, err := s.VerifyPassword("alice", "s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.ID)
+	assert.True(t, u.HasRole("admin"))
+
+	u, err = s.VerifyPassword("bob", "hunter2")
+	require.NoError(t, err)
+	assert.Equal(t, "u2", u.ID)
+
+	_, err = s.VerifyPassword("alice", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = s.VerifyPassword("carol", "s3cret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	s.Remove("bob")
+	_, err = s.VerifyPassword("bob", "hunter2")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
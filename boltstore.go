@@ -0,0 +1,129 @@
+package via
+
+import (
+	"encoding/binary"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var boltSessionBucket = []byte("via_sessions")
+
+// BoltStore is a pure-Go scs session store backed by a bbolt database file.
+// It suits single-binary deployments that cannot use cgo.
+type BoltStore struct {
+	db          *bbolt.DB
+	stopCleanup chan struct{}
+}
+
+// NewBoltStore creates a session store in db. Expired sessions are removed
+// every cleanupInterval; zero disables the cleanup goroutine.
+func NewBoltStore(db *bbolt.DB, cleanupInterval time.Duration) (*BoltStore, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltSessionBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &BoltStore{db: db}
+	if cleanupInterval > 0 {
+		s.stopCleanup = make(chan struct{})
+		go s.startCleanup(cleanupInterval, s.stopCleanup)
+	}
+	return s, nil
+}
+
+// Find returns the data for a session token. Expired sessions are not found.
+func (s *BoltStore) Find(token string) ([]byte, bool, error) {
+	var b []byte
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(boltSessionBucket).Get([]byte(token))
+		if v == nil || boltExpired(v, time.Now()) {
+			return nil
+		}
+		b = append([]byte(nil), v[8:]...)
+		return nil
+	})
+	return b, b != nil, err
+}
+
+// Commit stores the data for a session token until expiry.
+func (s *BoltStore) Commit(token string, b []byte, expiry time.Time) error {
+	v := make([]byte, 8+len(b))
+	binary.BigEndian.PutUint64(v, uint64(expiry.UnixNano()))
+	copy(v[8:], b)
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltSessionBucket).Put([]byte(token), v)
+	})
+}
+
+// Delete removes a session token and its data.
+func (s *BoltStore) Delete(token string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltSessionBucket).Delete([]byte(token))
+	})
+}
+
+// All returns the data of every session that has not expired.
+func (s *BoltStore) All() (map[string][]byte, error) {
+	all := make(map[string][]byte)
+	now := time.Now()
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltSessionBucket).ForEach(func(k, v []byte) error {
+			if !boltExpired(v, now) {
+				all[string(k)] = append([]byte(nil), v[8:]...)
+			}
+			return nil
+		})
+	})
+	return all, err
+}
+
+// StopCleanup stops the cleanup goroutine, e.g. before closing the database.
+func (s *BoltStore) StopCleanup() {
+	if s.stopCleanup != nil {
+		close(s.stopCleanup)
+		s.stopCleanup = nil
+	}
+}
+
+func (s *BoltStore) startCleanup(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.deleteExpired()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *BoltStore) deleteExpired() error {
+	now := time.Now()
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltSessionBucket)
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if boltExpired(v, now) {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func boltExpired(v []byte, now time.Time) bool {
+	return len(v) < 8 || int64(binary.BigEndian.Uint64(v)) < now.UnixNano()
+}
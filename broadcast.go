@@ -0,0 +1,124 @@
+package via
+
+// Filter selects live contexts for Broadcast. A nil Filter matches every context.
+type Filter func(c *Context) bool
+
+// ByRoute matches contexts of pages registered with the given route pattern,
+// e.g. "/orders/{id}".
+func ByRoute(route string) Filter {
+	return func(c *Context) bool {
+		return c.route == route
+	}
+}
+
+// ByRouteParam matches contexts whose page URL has the given path parameter value.
+func ByRouteParam(name, value string) Filter {
+	return func(c *Context) bool {
+		return c.GetPathParam(name) == value
+	}
+}
+
+// BySession matches contexts created by the browser session with the given id.
+func BySession(id string) Filter {
+	return func(c *Context) bool {
+		return id != "" && c.sessionKey() == id
+	}
+}
+
+// ByID matches the context with the given id, see Context.ID.
+func ByID(id string) Filter {
+	return func(c *Context) bool {
+		return c.id == id
+	}
+}
+
+// ByTag matches contexts tagged with key=value using Context.Tag.
+func ByTag(key, value string) Filter {
+	return func(c *Context) bool {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		v, ok := c.tags[key]
+		return ok && v == value
+	}
+}
+
+// And matches contexts that satisfy every given filter.
+func And(filters ...Filter) Filter {
+	return func(c *Context) bool {
+		for _, f := range filters {
+			if f != nil && !f(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Broadcast runs fn for every live page context matched by filter and returns
+// the number of matches.
+//
+// Each call runs in its own goroutine, serialized with the actions of that
+// context, so fn may read and mutate page state freely. Sync calls made by fn
+// are coalesced into a single sync per context and component. Broadcast does
+// not wait for fn to complete, which makes it safe to call from actions.
+//
+// Example:
+//
+//	v.Broadcast(via.And(via.ByRoute("/orders/{id}"), via.ByRouteParam("id", "42")), func(c *via.Context) {
+//		c.Sync()
+//	})
+func (v *V) Broadcast(filter Filter, fn func(c *Context)) int {
+	matches := v.contextsMatching(filter)
+	for _, c := range matches {
+		go v.runOnContext(c, fn)
+	}
+	v.logDebug(nil, "broadcast to %d context(s)", len(matches))
+	return len(matches)
+}
+
+// Contexts returns the live page contexts matched by filter, e.g. to inspect
+// page state in tests. Their state may only be changed through Broadcast.
+func (v *V) Contexts(filter Filter) []*Context {
+	return v.contextsMatching(filter)
+}
+
+func (v *V) contextsMatching(filter Filter) []*Context {
+	v.contextRegistryMutex.RLock()
+	defer v.contextRegistryMutex.RUnlock()
+	var matches []*Context
+	for _, c := range v.contextRegistry {
+		if filter == nil || filter(c) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+// Run calls fn with c serialized with the actions of its page, like
+// Broadcast does for a single context, and waits for it to return. Use it to
+// change page state from goroutines such as subscription handlers. Sync
+// calls made by fn are coalesced; fn is not called once c is disposed.
+//
+// Run must not be called from an action of the same page, which holds the
+// lock fn waits for; use Broadcast there.
+func (c *Context) Run(fn func(c *Context)) {
+	c.app.runOnContext(c, fn)
+}
+
+func (v *V) runOnContext(c *Context, fn func(c *Context)) {
+	defer func() {
+		if r := recover(); r != nil {
+			v.logErr(c, "broadcast failed: %v", r)
+		}
+	}()
+	select {
+	case <-c.Done():
+		return
+	default:
+	}
+	c.serialized(func() {
+		c.batchSyncs(func() {
+			fn(c)
+		})
+	})
+}
@@ -0,0 +1,156 @@
+package via
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBroadcastCtx(v *V, id, route string, params map[string]string) *Context {
+	c := newContext(id, route, v)
+	c.injectRouteParams(params)
+	c.View(func() h.H { return h.Div() })
+	c.patchChan = make(chan patch, 10)
+	v.registerCtx(c)
+	return c
+}
+
+func TestBroadcast_Filters(t *testing.T) {
+	v := New()
+	newBroadcastCtx(v, "o42", "/orders/{id}", map[string]string{"id": "42"})
+	o7 := newBroadcastCtx(v, "o7", "/orders/{id}", map[string]string{"id": "7"})
+	home := newBroadcastCtx(v, "home", "/", nil)
+	home.Tag("team", "infra")
+	o7.sessionID = "sess-1"
+
+	ids := func(f Filter) []string {
+		var out []string
+		for _, c := range v.contextsMatching(f) {
+			out = append(out, c.id)
+		}
+		return out
+	}
+
+	assert.ElementsMatch(t, []string{"o42", "o7", "home"}, ids(nil))
+	assert.ElementsMatch(t, []string{"o42", "o7"}, ids(ByRoute("/orders/{id}")))
+	assert.ElementsMatch(t, []string{"o42"}, ids(And(ByRoute("/orders/{id}"), ByRouteParam("id", "42"))))
+	assert.ElementsMatch(t, []string{"home"}, ids(ByTag("team", "infra")))
+	assert.Empty(t, ids(ByTag("team", "web")))
+	assert.ElementsMatch(t, []string{"o7"}, ids(BySession("sess-1")))
+	assert.Empty(t, ids(BySession("")))
+	assert.ElementsMatch(t, []string{"o7"}, ids(ByID("o7")))
+	assert.Equal(t, []*Context{o7}, v.Contexts(ByID("o7")))
+}
+
+func TestBroadcast_RunsCallbackAndCoalescesSyncs(t *testing.T) {
+	v := New()
+	c := newBroadcastCtx(v, "b1", "/", nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	n := v.Broadcast(ByRoute("/"), func(c *Context) {
+		defer wg.Done()
+		c.Sync()
+		c.Sync()
+		c.Sync()
+	})
+	assert.Equal(t, 1, n)
+	wg.Wait()
+
+	require.Eventually(t, func() bool { return len(c.patchChan) > 0 }, time.Second, time.Millisecond)
+	elementPatches := 0
+	for len(c.patchChan) > 0 {
+		if p := <-c.patchChan; p.typ == patchTypeElements {
+			elementPatches++
+		}
+	}
+	assert.Equal(t, 1, elementPatches, "syncs made during a broadcast should be coalesced")
+}
+
+func TestBroadcast_SerializedWithActions(t *testing.T) {
+	v := New()
+	c := newBroadcastCtx(v, "b2", "/", nil)
+
+	c.actionMu.Lock() // simulate an action in flight
+	ran := make(chan struct{})
+	v.Broadcast(nil, func(c *Context) { close(ran) })
+
+	select {
+	case <-ran:
+		t.Fatal("broadcast callback ran while an action held the context")
+	case <-time.After(20 * time.Millisecond):
+	}
+	c.actionMu.Unlock()
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast callback did not run after action finished")
+	}
+}
+
+func TestContextRun_SerializedWithActions(t *testing.T) {
+	v := New()
+	c := newBroadcastCtx(v, "r1", "/", nil)
+
+	c.actionMu.Lock() // simulate an action in flight
+	ran := make(chan struct{})
+	go c.Run(func(c *Context) {
+		c.Sync()
+		c.Sync()
+		close(ran)
+	})
+	select {
+	case <-ran:
+		t.Fatal("run callback ran while an action held the context")
+	case <-time.After(20 * time.Millisecond):
+	}
+	c.actionMu.Unlock()
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("run callback did not run after action finished")
+	}
+	require.Eventually(t, func() bool { return len(c.patchChan) > 0 }, time.Second, time.Millisecond)
+	assert.Len(t, c.patchChan, 1, "syncs made during run should be coalesced")
+
+	v.cleanupCtx(c)
+	called := false
+	c.Run(func(c *Context) { called = true })
+	assert.False(t, called, "run should not call fn on a disposed context")
+}
+
+func TestBroadcast_FromActionDoesNotDeadlock(t *testing.T) {
+	v := New()
+	done := make(chan struct{})
+	var trigger *actionTrigger
+	var cID string
+	v.Page("/", func(c *Context) {
+		cID = c.id
+		trigger = c.Action(func() {
+			v.Broadcast(ByRoute("/"), func(c *Context) { close(done) })
+		})
+		c.View(func() h.H { return h.Div() })
+	})
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	c, err := v.getCtx(cID)
+	require.NoError(t, err)
+
+	sigs := `{"via-ctx":"` + c.id + `","via-csrf":"` + c.csrfToken + `"}`
+	req := httptest.NewRequest("GET", "/_action/"+trigger.id+"?datastar="+url.QueryEscape(sigs), nil)
+	v.mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast from action never ran")
+	}
+}
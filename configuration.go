@@ -1,6 +1,7 @@
 package via
 
 import (
+	"net/netip"
 	"time"
 
 	"github.com/alexedwards/scs/v2"
@@ -54,7 +55,9 @@ type Options struct {
 	DatastarPath string
 
 	// PubSub enables publish/subscribe messaging. Use vianats.New() for an
-	// embedded NATS backend, or supply any PubSub implementation.
+	// embedded NATS backend, vianats.Connect() for an external NATS
+	// deployment, or supply any PubSub implementation. Backends with a
+	// SetLogger(zerolog.Logger) method receive the application logger.
 	PubSub PubSub
 
 	// ContextTTL is the maximum time a context may exist without an SSE
@@ -64,6 +67,80 @@ type Options struct {
 
 	// ActionRateLimit configures the default token-bucket rate limiter for
 	// action endpoints. Zero values use built-in defaults (10 req/s, burst 20).
-	// Set Rate to -1 to disable rate limiting entirely.
+	// Set Rate to -1 to disable rate limiting entirely. With a Key, one
+	// bucket is shared by all pages of a client rather than one per page.
 	ActionRateLimit RateLimitConfig
+
+	// PageRateLimit limits page loads, each of which creates a context, per
+	// client key (by IP if Key is nil). Zero Rate disables it; zero Burst
+	// allows Rate loads at once, rounded up.
+	PageRateLimit RateLimitConfig
+
+	// SSERateLimit limits SSE connects per client key (by IP if Key is nil).
+	// Zero Rate disables it; zero Burst allows Rate connects at once, rounded
+	// up.
+	SSERateLimit RateLimitConfig
+
+	// MaxContextsPerSession caps the live contexts, i.e. open pages, of one
+	// session. Further page loads are answered with 429. Zero is no cap.
+	MaxContextsPerSession int
+
+	// LimiterStore holds the buckets of keyed rate limits. Nil keeps them
+	// in memory; vianats.NewLimiterStore shares them between instances.
+	LimiterStore LimiterStore
+
+	// TrustedProxies lists the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers V.ClientIP believes.
+	TrustedProxies []netip.Prefix
+
+	// PresenceMeta returns user metadata, such as a name or avatar URL, to
+	// attach to presence members. It is called with the session of the
+	// page request when a context joins a presence topic.
+	PresenceMeta func(s *Session) map[string]string
+
+	// SessionClosedURL is where live contexts are redirected when their
+	// session is destroyed, renewed or revoked from another tab or instance.
+	// Empty reloads the current page.
+	SessionClosedURL string
+
+	// Policy resolves the permissions of WithPermission, RequirePermission
+	// and Context.Can against the current user. Nil grants a permission to
+	// users with a role of the same name; see RolePermissions.
+	Policy Policy
+
+	// Audit is called when a page or action is denied for lack of a
+	// permission, e.g. to record the attempt.
+	Audit func(e AuditEvent)
+
+	// CheckOrigin rejects requests to the action, SSE and session close
+	// endpoints that the browser marks as cross-site through the
+	// Sec-Fetch-Site or Origin header.
+	CheckOrigin bool
+
+	// TrustedOrigins lists other origins, e.g. "https://admin.example.com",
+	// that CheckOrigin lets through.
+	TrustedOrigins []string
+
+	// ContentSecurityPolicy is sent as the Content-Security-Policy header of
+	// pages, with "{nonce}" replaced by the nonce of the page. Empty sends
+	// no header. See DefaultContentSecurityPolicy.
+	ContentSecurityPolicy string
+
+	// Render sets how pages and patches are written: RenderAsIs (default),
+	// RenderPretty for reading patches in development, or RenderMinify for
+	// production.
+	Render RenderMode
 }
+
+// RenderMode sets how Via writes the HTML of pages and patches.
+type RenderMode int
+
+const (
+	// RenderAsIs writes HTML as h renders it.
+	RenderAsIs RenderMode = iota
+	// RenderPretty indents HTML, one element per line; see h.Pretty.
+	RenderPretty
+	// RenderMinify drops the whitespace the browser ignores, comments and
+	// the values of boolean attributes; see h.Minify.
+	RenderMinify
+)
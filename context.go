@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"maps"
 	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,6 +24,7 @@ type Context struct {
 	id                string
 	route             string
 	csrfToken         string
+	nonce             string
 	app               *V
 	view              func() h.H
 	routeParams       map[string]string
@@ -36,9 +39,21 @@ type Context struct {
 	reqCtx            context.Context
 	subscriptions     []Subscription
 	subsMu            sync.Mutex
+	disposeHooks      []func()
 	disposeOnce       sync.Once
 	createdAt         time.Time
 	sseConnected      atomic.Bool
+	sessionID         string
+	closing           atomic.Bool
+	tags              map[string]string
+	actionMu          sync.Mutex
+	syncMu            sync.Mutex
+	syncBatching      bool
+	syncPending       []*Context
+	redirectHeld      bool
+	heldRedirect      string
+	user              *User
+	userLoaded        bool
 }
 
 // View defines the UI rendered by this context.
@@ -89,6 +104,21 @@ func (c *Context) isComponent() bool {
 	return c.parentPageCtx != nil
 }
 
+// ID returns the unique identifier of this context. It is empty for the
+// throwaway context Via uses to validate page init funcs at registration.
+func (c *Context) ID() string {
+	return c.id
+}
+
+// Done returns a channel that is closed when the context is disposed.
+// Components share the channel of their parent page context.
+func (c *Context) Done() <-chan struct{} {
+	if c.isComponent() {
+		return c.parentPageCtx.ctxDisposedChan
+	}
+	return c.ctxDisposedChan
+}
+
 // Action registers an event handler and returns a trigger to that event that
 // that can be added to the view fn as any other via.h element.
 //
@@ -198,6 +228,68 @@ func (c *Context) Signal(v any) *signal {
 
 }
 
+// Computed creates a read-only signal that the browser derives from expr
+// whenever a signal it reads changes, so views can use it without a round
+// trip. The server reads its value like any other signal once the browser
+// sends it with the next action.
+//
+// deps are the signals expr reads. A dep from another page is logged and
+// sets Err on the result, as the browser would evaluate it to undefined.
+// Create computed signals in the page or component function, before the
+// page renders.
+//
+// Example:
+//
+//	price := c.Signal(10)
+//	qty := c.Signal(1)
+//	total := c.Computed(price.Expr()+" * "+qty.Expr(), price, qty)
+//	c.View(func() h.H {
+//		return h.Div(total.Text(), h.Button(h.Text("Buy"), total.AttrBind("data-total")))
+//	})
+func (c *Context) Computed(expr h.Expr, deps ...*signal) *signal {
+	sig := &signal{id: genRandID(), computed: expr, deps: deps}
+	p := c.pageCtx()
+	for _, dep := range deps {
+		if _, ok := p.signals.Load(dep.id); !ok {
+			c.app.logErr(c, "computed signal '%s' depends on unknown signal '%s'", sig.id, dep.id)
+			sig.err = fmt.Errorf("context '%s' computed signal '%s': unknown dependency '%s'", c.id, sig.id, dep.id)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p.signals.Store(sig.id, sig)
+	return sig
+}
+
+// computedHead returns the head elements declaring the computed signals of
+// the page, in a stable order. Their dependencies are declared first, as
+// the SSE stream only sends signal values once the page has loaded.
+func (c *Context) computedHead() []h.H {
+	var sigs []*signal
+	c.signals.Range(func(_, value any) bool {
+		if sig, ok := value.(*signal); ok && sig.computed != "" && sig.err == nil {
+			sigs = append(sigs, sig)
+		}
+		return true
+	})
+	if len(sigs) == 0 {
+		return nil
+	}
+	slices.SortFunc(sigs, func(a, b *signal) int { return strings.Compare(a.id, b.id) })
+	deps := make(map[string]any)
+	attrs := make([]h.H, len(sigs))
+	for i, sig := range sigs {
+		for _, dep := range sig.deps {
+			if dep.computed == "" {
+				deps[dep.id] = fmt.Sprintf("%v", dep.val)
+			}
+		}
+		attrs[i] = h.DataComputed(sig.id, sig.computed)
+	}
+	return []h.H{h.Meta(h.DataSignals(deps, h.ModIfMissing)), h.Meta(attrs...)}
+}
+
 func (c *Context) injectSignals(sigs map[string]any) {
 	if sigs == nil {
 		c.app.logErr(c, "signal injection failed: nil signals")
@@ -244,7 +336,7 @@ func (c *Context) prepareSignalsForPatch() map[string]any {
 				c.app.logWarn(c, "signal '%s' is out of sync: %v", sig.id, sig.err)
 				return true
 			}
-			if sig.changed {
+			if sig.changed && sig.computed == "" {
 				updatedSigs[sigID.(string)] = fmt.Sprintf("%v", sig.val)
 			}
 		}
@@ -263,11 +355,71 @@ func (c *Context) sendPatch(p patch) {
 	}
 }
 
+// pageCtx returns the page context this context belongs to.
+func (c *Context) pageCtx() *Context {
+	if c.isComponent() {
+		return c.parentPageCtx
+	}
+	return c
+}
+
+// serialized runs fn while holding the page context's action lock, so it
+// never interleaves with action handlers of the same page.
+func (c *Context) serialized(fn func()) {
+	p := c.pageCtx()
+	p.actionMu.Lock()
+	defer p.actionMu.Unlock()
+	fn()
+}
+
+// batchSyncs runs fn with Sync calls on the page context and its components
+// deferred, then syncs each of them at most once.
+func (c *Context) batchSyncs(fn func()) {
+	p := c.pageCtx()
+	p.syncMu.Lock()
+	p.syncBatching = true
+	p.syncMu.Unlock()
+
+	defer func() {
+		p.syncMu.Lock()
+		pending := p.syncPending
+		p.syncPending = nil
+		p.syncBatching = false
+		p.syncMu.Unlock()
+		for _, pc := range pending {
+			pc.sync()
+		}
+	}()
+	fn()
+}
+
+// deferSync records c as pending if its page is batching syncs and reports
+// whether it did.
+func (c *Context) deferSync() bool {
+	p := c.pageCtx()
+	p.syncMu.Lock()
+	defer p.syncMu.Unlock()
+	if !p.syncBatching {
+		return false
+	}
+	if !slices.Contains(p.syncPending, c) {
+		p.syncPending = append(p.syncPending, c)
+	}
+	return true
+}
+
 // Sync pushes the current view state and signal changes to the browser immediately
 // over the live SSE event stream.
 func (c *Context) Sync() {
+	if c.deferSync() {
+		return
+	}
+	c.sync()
+}
+
+func (c *Context) sync() {
 	elemsPatch := bytes.NewBuffer(make([]byte, 0))
-	if err := c.view().Render(elemsPatch); err != nil {
+	if err := c.app.render(elemsPatch, c.view()); err != nil {
 		c.app.logErr(c, "sync view failed: %v", err)
 		return
 	}
@@ -305,7 +457,7 @@ func (c *Context) SyncElements(elem ...h.H) {
 			c.app.logWarn(c, "sync elements failed: element at idx=%d is nil", idx)
 			continue
 		}
-		if err := el.Render(b); err != nil {
+		if err := c.app.render(b, el); err != nil {
 			c.app.logWarn(c, "sync elements failed: element at idx=%d has invalid html", idx)
 			continue
 		}
@@ -339,9 +491,36 @@ func (c *Context) Redirect(url string) {
 		c.app.logWarn(c, "redirect failed: empty url")
 		return
 	}
+	p := c.pageCtx()
+	p.mu.Lock()
+	if p.redirectHeld {
+		p.heldRedirect = url
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
 	c.sendPatch(patch{patchTypeRedirect, url})
 }
 
+// holdRedirects delays Redirect calls on the page until release is called,
+// which sends the last one.
+func (c *Context) holdRedirects() (release func()) {
+	p := c.pageCtx()
+	p.mu.Lock()
+	p.redirectHeld = true
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		url := p.heldRedirect
+		p.redirectHeld = false
+		p.heldRedirect = ""
+		p.mu.Unlock()
+		if url != "" {
+			c.sendPatch(patch{patchTypeRedirect, url})
+		}
+	}
+}
+
 // Redirectf navigates the browser to a URL constructed from the format string and arguments.
 func (c *Context) Redirectf(format string, a ...any) {
 	c.Redirect(fmt.Sprintf(format, a...))
@@ -362,15 +541,39 @@ func (c *Context) ReplaceURLf(format string, a ...any) {
 	c.ReplaceURL(fmt.Sprintf(format, a...))
 }
 
-// dispose idempotently tears down this context: unsubscribes all pubsub
-// subscriptions and closes ctxDisposedChan to stop routines and exit the SSE loop.
+// dispose idempotently tears down this context: runs dispose hooks, unsubscribes
+// all pubsub subscriptions and closes ctxDisposedChan to stop routines and exit
+// the SSE loop.
 func (c *Context) dispose() {
 	c.disposeOnce.Do(func() {
+		c.runDisposeHooks()
 		c.unsubscribeAll()
 		c.stopAllRoutines()
 	})
 }
 
+// OnDispose registers fn to run when the page context is disposed, e.g. to
+// release resources tied to the browser tab. No-ops during panic-check init.
+func (c *Context) OnDispose(fn func()) {
+	if c.id == "" {
+		return
+	}
+	p := c.pageCtx()
+	p.subsMu.Lock()
+	defer p.subsMu.Unlock()
+	p.disposeHooks = append(p.disposeHooks, fn)
+}
+
+func (c *Context) runDisposeHooks() {
+	c.subsMu.Lock()
+	hooks := c.disposeHooks
+	c.disposeHooks = nil
+	c.subsMu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
 // stopAllRoutines closes ctxDisposedChan, broadcasting to all listening
 // goroutines (OnIntervalRoutine, SSE loop) that this context is done.
 func (c *Context) stopAllRoutines() {
@@ -418,16 +621,99 @@ func (c *Context) GetPathParam(param string) string {
 	return ""
 }
 
+// Tag labels this context with a key/value pair that ByTag filters can match
+// when broadcasting. Components tag their page context.
+//
+// Example:
+//
+//	c.Tag("team", "infra")
+func (c *Context) Tag(key, value string) {
+	p := c.pageCtx()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tags[key] = value
+}
+
+// Presence joins this context to the presence topic and returns a handle to
+// list who else is present. An empty topic defaults to the page path, e.g.
+// "/orders/42" for the route "/orders/{id}". Views that read the handle are
+// re-synced whenever members join or leave, on this or any other instance
+// sharing the configured PubSub. The context leaves when it is disposed.
+//
+// Example:
+//
+//	viewers := c.Presence("")
+//
+//	c.View(func() h.H {
+//		return h.P(h.Textf("%d people are viewing this ticket", viewers.Count()))
+//	})
+func (c *Context) Presence(topic string) *Presence {
+	if topic == "" {
+		topic = c.path()
+	}
+	p := &Presence{topic: topic, tracker: c.app.presenceTracker()}
+	if c.id == "" {
+		return p
+	}
+	p.tracker.join(c, topic)
+	return p
+}
+
+// path rebuilds the page URL path from the route pattern and its parameters.
+func (c *Context) path() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	path := c.route
+	for k, v := range c.routeParams {
+		path = strings.ReplaceAll(path, "{"+k+"}", v)
+	}
+	return path
+}
+
 // Session returns the session for this context.
 // Session data persists across page views for the same browser.
 // Returns a no-op session if no SessionManager is configured.
 func (c *Context) Session() *Session {
 	return &Session{
-		ctx:     c.reqCtx,
+		ctx:     c.requestCtx(),
 		manager: c.app.sessionManager,
+		app:     c.app,
+		c:       c,
+		token:   c.sessionKey(),
 	}
 }
 
+// requestCtx returns the context of the page load or action being handled
+// for the page, or nil if none is in flight.
+func (c *Context) requestCtx() context.Context {
+	p := c.pageCtx()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.reqCtx
+}
+
+func (c *Context) setRequestCtx(ctx context.Context) {
+	p := c.pageCtx()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reqCtx = ctx
+}
+
+// sessionKey returns the token of the session that opened the page.
+func (c *Context) sessionKey() string {
+	p := c.pageCtx()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.sessionID
+}
+
+func (c *Context) setSessionKey(id string) {
+	p := c.pageCtx()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessionID = id
+}
+
 // Publish sends data to the given subject via the configured PubSub backend.
 // Returns an error if no PubSub is configured. No-ops during panic-check init.
 func (c *Context) Publish(subject string, data []byte) error {
@@ -486,14 +772,16 @@ func newContext(id string, route string, v *V) *Context {
 		id:                id,
 		route:             route,
 		csrfToken:         genCSRFToken(),
+		nonce:             genCSRFToken(),
 		routeParams:       make(map[string]string),
 		app:               v,
 		componentRegistry: make(map[string]*Context),
-		actionLimiter:     newLimiter(v.actionRateLimit, defaultActionRate, defaultActionBurst),
+		actionLimiter:     v.contextLimiter(),
 		actionRegistry:    make(map[string]actionEntry),
 		signals:           new(sync.Map),
 		patchChan:         make(chan patch, 1),
 		ctxDisposedChan:   make(chan struct{}, 1),
 		createdAt:         time.Now(),
+		tags:              make(map[string]string),
 	}
 }
@@ -0,0 +1,87 @@
+package via
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ryanhamamura/via/h"
+	"golang.org/x/net/html"
+)
+
+// DefaultContentSecurityPolicy is a strict policy for Via apps, for use as
+// Options.ContentSecurityPolicy. Via's own scripts, AppendToHead and
+// AppendToFoot scripts and ExecScript run through the page nonce, so no
+// 'unsafe-inline' is needed for scripts. Datastar still needs
+// 'unsafe-eval': it compiles data-* expressions with the Function
+// constructor. Inline styles stay allowed for h.Style and data-style.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'nonce-{nonce}' 'unsafe-eval'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"object-src 'none'; base-uri 'self'; frame-ancestors 'self'"
+
+// Nonce returns the CSP nonce of the page. Via adds it to the scripts it
+// renders; add it to inline scripts of your own views.
+//
+// Example:
+//
+//	h.Script(h.Attr("nonce", c.Nonce()), h.Raw("initMap()"))
+func (c *Context) Nonce() string {
+	return c.pageCtx().nonce
+}
+
+// contentSecurityPolicy returns the policy header of the page of c, or ""
+// if none is configured.
+func (v *V) contentSecurityPolicy(c *Context) string {
+	return strings.ReplaceAll(v.cfg.ContentSecurityPolicy, "{nonce}", c.nonce)
+}
+
+// withNonce adds the nonce of c to the script elements of n that have none,
+// if a policy is configured. Scripts are found with the HTML tokenizer, so
+// "<script" inside script text is left alone.
+func (v *V) withNonce(c *Context, n h.H) h.H {
+	if v.cfg.ContentSecurityPolicy == "" {
+		return n
+	}
+	var b strings.Builder
+	if err := n.Render(&b); err != nil {
+		return n
+	}
+	var out strings.Builder
+	z := html.NewTokenizer(strings.NewReader(b.String()))
+	for {
+		tt := z.Next()
+		raw := string(z.Raw()) // TagName lowercases the raw bytes in place
+		if tt == html.ErrorToken {
+			out.WriteString(raw)
+			break
+		}
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			if name, hasAttr := z.TagName(); string(name) == "script" && !(hasAttr && hasNonce(z)) {
+				raw = `<script nonce="` + c.nonce + `"` + raw[len("<script"):]
+			}
+		}
+		out.WriteString(raw)
+	}
+	return h.Raw(out.String())
+}
+
+// hasNonce reports whether the current tag of z has a nonce attribute.
+func hasNonce(z *html.Tokenizer) bool {
+	for more := true; more; {
+		var key []byte
+		key, _, more = z.TagAttr()
+		if string(key) == "nonce" {
+			return true
+		}
+	}
+	return false
+}
+
+// closeBeaconScript tells the server when the page is left, so its context
+// is disposed right away rather than by the reaper.
+func closeBeaconScript(c *Context) h.H {
+	msg, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+	return h.Script(h.Attr("nonce", c.nonce), h.Rawf(
+		`window.addEventListener('beforeunload', () => navigator.sendBeacon('/_session/close', JSON.stringify(%s)));`, msg))
+}
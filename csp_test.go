@@ -0,0 +1,100 @@
+package via
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage_ContentSecurityPolicyNonce(t *testing.T) {
+	v := New()
+	v.Config(Options{ContentSecurityPolicy: DefaultContentSecurityPolicy})
+	v.AppendToHead(h.Script(h.Raw("headInit()")), h.Link(h.Rel("stylesheet"), h.Href("/app.css")))
+	v.AppendToFoot(h.Div(h.Script(h.Src("/foot.js"))))
+	var nonce string
+	v.Page("/", func(c *Context) {
+		nonce = c.Nonce()
+		c.View(func() h.H { return h.Div(h.Text("<script>not a tag</script>")) })
+	})
+
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	body := w.Body.String()
+
+	require.NotEmpty(t, nonce)
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "script-src 'self' 'nonce-"+nonce+"'")
+	scripts := regexp.MustCompile(`<script[^>]*>`).FindAllString(body, -1)
+	assert.Len(t, scripts, 4, "datastar, head include, close beacon, foot include")
+	for _, s := range scripts {
+		assert.Contains(t, s, `nonce="`+nonce+`"`)
+	}
+	assert.NotContains(t, body, "beforeunload', (evt)", "beacon no longer runs through data-init")
+	assert.Contains(t, body, "&lt;script&gt;not a tag")
+
+	first := nonce
+	w = httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.NotEqual(t, first, nonce, "nonce is per page")
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), nonce)
+}
+
+func TestWithNonce(t *testing.T) {
+	v := New()
+	c := newContext("n1", "/", v)
+	n := h.Raw(`<script nonce="own">a()</script><script>document.write("<script>b()<\/script>")</script><SCRIPT src="/c.js"></SCRIPT>`)
+	rendered := func(n h.H) string {
+		var b strings.Builder
+		require.NoError(t, n.Render(&b))
+		return b.String()
+	}
+	assert.Equal(t, rendered(n), rendered(v.withNonce(c, n)), "without a policy includes are not touched")
+
+	v.Config(Options{ContentSecurityPolicy: DefaultContentSecurityPolicy})
+	assert.Equal(t, `<script nonce="own">a()</script>`+
+		`<script nonce="`+c.nonce+`">document.write("<script>b()<\/script>")</script>`+
+		`<script nonce="`+c.nonce+`" src="/c.js"></SCRIPT>`, rendered(v.withNonce(c, n)))
+}
+
+func TestPage_NoContentSecurityPolicyByDefault(t *testing.T) {
+	v := New()
+	v.Page("/", func(c *Context) { c.View(func() h.H { return h.Div() }) })
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Empty(t, w.Header().Get("Content-Security-Policy"))
+}
+
+func TestExecScript_CarriesNonce(t *testing.T) {
+	v := New()
+	c := newBroadcastCtx(v, "c1", "/", nil)
+	srv := httptest.NewServer(v.mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sigs, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/_sse?datastar="+url.QueryEscape(string(sigs)), nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	c.ExecScript("alert(1)")
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if line := sc.Text(); strings.Contains(line, "alert(1)") {
+			assert.Contains(t, line, `<script nonce="`+c.Nonce()+`"`)
+			return
+		}
+	}
+	t.Fatal("script patch not received")
+}
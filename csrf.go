@@ -0,0 +1,59 @@
+package via
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+var (
+	errInvalidCSRF     = errors.New("invalid CSRF token")
+	errSessionMismatch = errors.New("context belongs to another session")
+	errCrossOrigin     = errors.New("cross-origin request")
+)
+
+// verifyCtxRequest checks that a request to an internal endpoint for c
+// carries the CSRF token of c and comes from the browser session that
+// created c, so a context id leaked in logs cannot be used from another
+// browser.
+func (v *V) verifyCtxRequest(c *Context, r *http.Request, csrfToken string) error {
+	if subtle.ConstantTimeCompare([]byte(csrfToken), []byte(c.csrfToken)) != 1 {
+		return errInvalidCSRF
+	}
+	// contexts created before a session was started accept any session,
+	// which then binds them on its first commit
+	key := c.sessionKey()
+	if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(v.sessionToken(r.Context()))) != 1 {
+		return errSessionMismatch
+	}
+	return nil
+}
+
+// checkOrigin rejects cross-origin requests to internal endpoints when
+// Options.CheckOrigin is set. Browsers label requests with Sec-Fetch-Site;
+// older ones only send Origin. Requests without either are not from a
+// browser page and pass.
+func (v *V) checkOrigin(r *http.Request) error {
+	if !v.cfg.CheckOrigin {
+		return nil
+	}
+	origin := r.Header.Get("Origin")
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return nil
+	case "":
+		if origin == "" {
+			return nil
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return nil
+		}
+	}
+	if origin != "" && slices.Contains(v.cfg.TrustedOrigins, origin) {
+		return nil
+	}
+	return errCrossOrigin
+}
@@ -0,0 +1,112 @@
+package via
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionClose_RequiresCSRFToken(t *testing.T) {
+	v := New()
+	c := newBroadcastCtx(v, "c1", "/", nil)
+	closeCtx := func(body string) int {
+		w := httptest.NewRecorder()
+		v.mux.ServeHTTP(w, httptest.NewRequest("POST", "/_session/close", strings.NewReader(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, closeCtx("c1"), "bare context id")
+	assert.Equal(t, http.StatusForbidden, closeCtx(`{"via-ctx":"c1","via-csrf":"guess"}`))
+	_, err := v.getCtx("c1")
+	require.NoError(t, err, "context survives a forged close")
+
+	body, _ := json.Marshal(map[string]string{"via-ctx": "c1", "via-csrf": c.csrfToken})
+	assert.Equal(t, http.StatusOK, closeCtx(string(body)))
+	_, err = v.getCtx("c1")
+	assert.Error(t, err)
+}
+
+func TestSSE_RequiresCSRFToken(t *testing.T) {
+	v := New()
+	newBroadcastCtx(v, "c1", "/", nil)
+	sigs := url.QueryEscape(`{"via-ctx":"c1","via-csrf":"guess"}`)
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/_sse?datastar="+sigs, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	c, err := v.getCtx("c1")
+	require.NoError(t, err)
+	assert.False(t, c.sseConnected.Load())
+}
+
+func TestContextBoundToCreatingSession(t *testing.T) {
+	v := New()
+	ran := 0
+	var act *actionTrigger
+	v.Page("/", func(c *Context) {
+		act = c.Action(func() { ran++ })
+		c.View(func() h.H { return h.Div() })
+	})
+	handler := v.sessionManager.LoadAndSave(v.mux)
+	_, owner := newStoredSession(t, v.sessionManager)
+	_, other := newStoredSession(t, v.sessionManager)
+
+	get := func(target, token string) int {
+		r := httptest.NewRequest("GET", target, nil)
+		if token != "" {
+			r.AddCookie(&http.Cookie{Name: v.sessionManager.Cookie.Name, Value: token})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	require.Equal(t, http.StatusOK, get("/", owner))
+	c := v.ContextsForSession(owner)[0]
+	sigs, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+	target := "/_action/" + act.id + "?datastar=" + url.QueryEscape(string(sigs))
+
+	assert.Equal(t, http.StatusForbidden, get(target, other), "another browser's session")
+	assert.Equal(t, http.StatusForbidden, get(target, ""), "no session")
+	assert.Zero(t, ran)
+	assert.Equal(t, http.StatusOK, get(target, owner))
+	assert.Equal(t, 1, ran)
+}
+
+func TestCheckOrigin(t *testing.T) {
+	v := New()
+	req := func(site, origin string) *http.Request {
+		r := httptest.NewRequest("GET", "http://app.test/_action/x", nil)
+		if site != "" {
+			r.Header.Set("Sec-Fetch-Site", site)
+		}
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return r
+	}
+	assert.NoError(t, v.checkOrigin(req("cross-site", "https://evil.test")), "disabled by default")
+
+	v.Config(Options{CheckOrigin: true, TrustedOrigins: []string{"https://admin.test"}})
+	for _, tc := range []struct {
+		site, origin string
+		ok           bool
+	}{
+		{"same-origin", "http://app.test", true},
+		{"none", "", true},
+		{"", "", true},
+		{"", "http://app.test", true},
+		{"", "https://evil.test", false},
+		{"cross-site", "https://evil.test", false},
+		{"same-site", "https://sub.app.test", false},
+		{"cross-site", "https://admin.test", true},
+	} {
+		err := v.checkOrigin(req(tc.site, tc.origin))
+		assert.Equal(t, tc.ok, err == nil, "%q %q", tc.site, tc.origin)
+	}
+}
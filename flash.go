@@ -0,0 +1,64 @@
+package via
+
+import "github.com/ryanhamamura/via/h"
+
+const flashSessionKey = "via.flash"
+
+// Flash is a one-time message for the user, such as "Order saved".
+type Flash struct {
+	// Kind classifies the message, e.g. "success", "info" or "error".
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Flash queues a message in the session to be shown on the next page the
+// browser loads, so it survives a Redirect. No-ops if no SessionManager is
+// configured.
+//
+// Example:
+//
+//	save := c.Action(func() {
+//		(...)
+//		c.Flash("success", "Order saved")
+//		c.Redirect("/orders")
+//	})
+func (c *Context) Flash(kind, msg string) {
+	s := c.Session()
+	flashes, _ := SessionGet[[]Flash](s, flashSessionKey)
+	if err := SessionSet(s, flashSessionKey, append(flashes, Flash{Kind: kind, Message: msg})); err != nil {
+		c.app.logErr(c, "flash failed: %v", err)
+	}
+}
+
+// Flashes returns the queued flash messages and clears them from the session.
+func (c *Context) Flashes() []Flash {
+	flashes, _ := SessionPop[[]Flash](c.Session(), flashSessionKey)
+	return flashes
+}
+
+// FlashMessages takes the queued flash messages when the page loads and
+// returns a view fn that renders them. Each message is shown once: reloading
+// the page does not show it again.
+//
+// Messages render as
+//
+//	<div class="via-flash"><div class="via-flash-success" role="alert">Order saved</div></div>
+//
+// Example:
+//
+//	v.Page("/orders", func(c *via.Context) {
+//		flashes := c.FlashMessages()
+//		c.View(func() h.H {
+//			return h.Div(flashes(), (...))
+//		})
+//	})
+func (c *Context) FlashMessages() func() h.H {
+	flashes := c.Flashes()
+	return func() h.H {
+		items := []h.H{h.Class("via-flash")}
+		for _, f := range flashes {
+			items = append(items, h.Div(h.Class("via-flash-"+f.Kind), h.Role("alert"), h.Text(f.Message)))
+		}
+		return h.Div(items...)
+	}
+}
@@ -0,0 +1,86 @@
+package via
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cart struct {
+	Items []string `json:"items"`
+	Total int      `json:"total"`
+}
+
+func TestSessionGetSet_Typed(t *testing.T) {
+	v := New()
+	sm := v.sessionManager
+	ctx, token := newStoredSession(t, sm)
+	s := &Session{ctx: ctx, manager: sm}
+
+	_, ok := SessionGet[cart](s, "cart")
+	assert.False(t, ok)
+
+	require.NoError(t, SessionSet(s, "cart", cart{Items: []string{"tea"}, Total: 3}))
+	_, _, err := sm.Commit(ctx)
+	require.NoError(t, err)
+
+	// a fresh request sees the struct without gob registration
+	ctx, err = sm.Load(context.Background(), token)
+	require.NoError(t, err)
+	s = &Session{ctx: ctx, manager: sm}
+	got, ok := SessionGet[cart](s, "cart")
+	require.True(t, ok)
+	assert.Equal(t, cart{Items: []string{"tea"}, Total: 3}, got)
+
+	_, ok = SessionGet[int](s, "cart")
+	assert.False(t, ok, "mismatched types should not decode")
+
+	got, ok = SessionPop[cart](s, "cart")
+	assert.True(t, ok)
+	assert.Equal(t, 3, got.Total)
+	assert.False(t, s.Exists("cart"))
+
+	var noop Session
+	_, ok = SessionGet[cart](&noop, "cart")
+	assert.False(t, ok)
+}
+
+func TestFlash_SurvivesRedirectAndShowsOnce(t *testing.T) {
+	v := New()
+	sm := v.sessionManager
+	ctx, token := newStoredSession(t, sm)
+
+	// the action request queues flashes before redirecting
+	action := newContext("a", "/orders/new", v)
+	action.reqCtx = ctx
+	action.Flash("success", "Order saved")
+	action.Flash("info", "Email sent")
+	_, _, err := sm.Commit(ctx)
+	require.NoError(t, err)
+
+	// the next page load renders them
+	ctx, err = sm.Load(context.Background(), token)
+	require.NoError(t, err)
+	page := newContext("b", "/orders", v)
+	page.reqCtx = ctx
+	flashes := page.FlashMessages()
+	var b bytes.Buffer
+	require.NoError(t, flashes().Render(&b))
+	assert.Equal(t, `<div class="via-flash"><div class="via-flash-success" role="alert">Order saved</div>`+
+		`<div class="via-flash-info" role="alert">Email sent</div></div>`, b.String())
+
+	// re-rendering keeps them on the page, but the session is cleared
+	b.Reset()
+	require.NoError(t, flashes().Render(&b))
+	assert.Contains(t, b.String(), "Order saved")
+	_, _, err = sm.Commit(ctx)
+	require.NoError(t, err)
+	ctx, err = sm.Load(context.Background(), token)
+	require.NoError(t, err)
+	reload := newContext("c", "/orders", v)
+	reload.reqCtx = ctx
+	assert.Empty(t, reload.Flashes())
+}
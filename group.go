@@ -0,0 +1,54 @@
+package via
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Guard runs before a page is created for a request. It returns true to let
+// the page load, or writes a response itself, e.g. a redirect to a login
+// page, and returns false.
+type Guard func(w http.ResponseWriter, r *http.Request) bool
+
+// Group registers pages under a common route prefix that share guards.
+//
+// Example:
+//
+//	admin := v.Group("/admin", auth.RequireAuth())
+//	admin.Page("/users", usersPage) // served at /admin/users
+type Group struct {
+	v      *V
+	prefix string
+	guards []Guard
+}
+
+// Group creates a group of pages under prefix, guarded by guards.
+func (v *V) Group(prefix string, guards ...Guard) *Group {
+	return &Group{v: v, prefix: strings.TrimSuffix(prefix, "/"), guards: guards}
+}
+
+// Page registers a page at the group prefix joined with route. The group
+// guards run before the page's own guards.
+func (g *Group) Page(route string, initContextFn func(c *Context), guards ...Guard) {
+	g.v.Page(g.route(route), initContextFn, append(slices.Clone(g.guards), guards...)...)
+}
+
+// Group creates a nested group that runs the guards of g before its own.
+func (g *Group) Group(prefix string, guards ...Guard) *Group {
+	return &Group{
+		v:      g.v,
+		prefix: g.route(strings.TrimSuffix(prefix, "/")),
+		guards: append(slices.Clone(g.guards), guards...),
+	}
+}
+
+func (g *Group) route(route string) string {
+	if route == "" || route == "/" {
+		if g.prefix == "" {
+			return "/"
+		}
+		return g.prefix
+	}
+	return g.prefix + route
+}
@@ -0,0 +1,50 @@
+package via
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_PrefixesRoutesAndRunsGuardsInOrder(t *testing.T) {
+	v := New()
+	var calls []string
+	guard := func(name string, allow bool) Guard {
+		return func(w http.ResponseWriter, r *http.Request) bool {
+			calls = append(calls, name)
+			if !allow {
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+			}
+			return allow
+		}
+	}
+	page := func(c *Context) { c.View(func() h.H { return h.P(h.Text("secret")) }) }
+
+	admin := v.Group("/admin/", guard("admin", true))
+	admin.Page("/", page)
+	admin.Group("/billing", guard("billing", true)).Page("/invoices", page, guard("page", true))
+	admin.Page("/locked", page, guard("locked", false))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		calls = nil
+		w := httptest.NewRecorder()
+		v.HTTPServeMux().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"admin"}, calls)
+
+	w = get("/admin/billing/invoices")
+	assert.Contains(t, w.Body.String(), "secret")
+	assert.Equal(t, []string{"admin", "billing", "page"}, calls)
+
+	w = get("/admin/locked")
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+	assert.Len(t, v.contextRegistry, 2, "the locked page should not create a context")
+}
@@ -1,44 +1,681 @@
 package h
 
-import gh "maragu.dev/gomponents/html"
+import (
+	"strconv"
 
-func Href(v string) H {
-	return gh.Href(v)
+	gc "maragu.dev/gomponents/components"
+	gh "maragu.dev/gomponents/html"
+)
+
+func Accept(v string) H {
+	return gh.Accept(v)
 }
 
-func Type(v string) H {
-	return gh.Type(v)
+func Action(v string) H {
+	return gh.Action(v)
 }
 
-func Src(v string) H {
-	return gh.Src(v)
+func Alt(v string) H {
+	return gh.Alt(v)
+}
+
+func As(v string) H {
+	return gh.As(v)
+}
+
+func AutoComplete(v string) H {
+	return gh.AutoComplete(v)
+}
+
+func Charset(v string) H {
+	return gh.Charset(v)
+}
+
+func CiteAttr(v string) H {
+	return gh.CiteAttr(v)
+}
+
+func Class(v string) H {
+	return gh.Class(v)
+}
+
+func Cols(v string) H {
+	return gh.Cols(v)
+}
+
+func ColSpan(v string) H {
+	return gh.ColSpan(v)
+}
+
+func Content(v string) H {
+	return gh.Content(v)
+}
+
+func CrossOrigin(v string) H {
+	return gh.CrossOrigin(v)
+}
+
+func DateTime(v string) H {
+	return gh.DateTime(v)
+}
+
+func Dir(v string) H {
+	return gh.Dir(v)
+}
+
+func Download(v string) H {
+	return gh.Download(v)
+}
+
+func Draggable(v string) H {
+	return gh.Draggable(v)
+}
+
+func EncType(v string) H {
+	return gh.EncType(v)
+}
+
+func For(v string) H {
+	return gh.For(v)
+}
+
+func FormAction(v string) H {
+	return gh.FormAction(v)
+}
+
+func FormAttr(v string) H {
+	return gh.FormAttr(v)
+}
+
+func FormEncType(v string) H {
+	return gh.FormEncType(v)
+}
+
+func FormMethod(v string) H {
+	return gh.FormMethod(v)
+}
+
+func FormTarget(v string) H {
+	return gh.FormTarget(v)
+}
+
+func Height(v string) H {
+	return gh.Height(v)
+}
+
+func Href(v string) H {
+	return gh.Href(v)
 }
 
 func ID(v string) H {
 	return gh.ID(v)
 }
 
-func Value(v string) H {
-	return gh.Value(v)
+func Integrity(v string) H {
+	return gh.Integrity(v)
+}
+
+func LabelAttr(v string) H {
+	return gh.LabelAttr(v)
+}
+
+func Lang(v string) H {
+	return gh.Lang(v)
+}
+
+func List(v string) H {
+	return gh.List(v)
+}
+
+func Loading(v string) H {
+	return gh.Loading(v)
+}
+
+func Max(v string) H {
+	return gh.Max(v)
+}
+
+func MaxLength(v string) H {
+	return gh.MaxLength(v)
+}
+
+func Method(v string) H {
+	return gh.Method(v)
+}
+
+func Min(v string) H {
+	return gh.Min(v)
+}
+
+func MinLength(v string) H {
+	return gh.MinLength(v)
+}
+
+func Name(v string) H {
+	return gh.Name(v)
+}
+
+func Pattern(v string) H {
+	return gh.Pattern(v)
 }
 
 func Placeholder(v string) H {
 	return gh.Placeholder(v)
 }
 
-func Rel(v string) H {
-	return gh.Rel(v)
+func PopoverTarget(v string) H {
+	return gh.PopoverTarget(v)
 }
 
-func Class(v string) H {
-	return gh.Class(v)
+func PopoverTargetAction(v string) H {
+	return gh.PopoverTargetAction(v)
+}
+
+func Poster(v string) H {
+	return gh.Poster(v)
+}
+
+func Preload(v string) H {
+	return gh.Preload(v)
+}
+
+func ReferrerPolicy(v string) H {
+	return gh.ReferrerPolicy(v)
+}
+
+func Rel(v string) H {
+	return gh.Rel(v)
 }
 
 func Role(v string) H {
 	return gh.Role(v)
 }
 
+func Rows(v string) H {
+	return gh.Rows(v)
+}
+
+func RowSpan(v string) H {
+	return gh.RowSpan(v)
+}
+
+func Scope(v string) H {
+	return gh.Scope(v)
+}
+
+func Src(v string) H {
+	return gh.Src(v)
+}
+
+func SrcSet(v string) H {
+	return gh.SrcSet(v)
+}
+
+func Step(v string) H {
+	return gh.Step(v)
+}
+
+func TabIndex(v string) H {
+	return gh.TabIndex(v)
+}
+
+func Target(v string) H {
+	return gh.Target(v)
+}
+
+func Type(v string) H {
+	return gh.Type(v)
+}
+
+func Value(v string) H {
+	return gh.Value(v)
+}
+
+func Width(v string) H {
+	return gh.Width(v)
+}
+
+func AccessKey(v string) H {
+	return Attr("accesskey", v)
+}
+
+func Allow(v string) H {
+	return Attr("allow", v)
+}
+
+func AutoCapitalize(v string) H {
+	return Attr("autocapitalize", v)
+}
+
+func Capture(v string) H {
+	return Attr("capture", v)
+}
+
+func ContentEditable(v string) H {
+	return Attr("contenteditable", v)
+}
+
+func Coords(v string) H {
+	return Attr("coords", v)
+}
+
+func DirName(v string) H {
+	return Attr("dirname", v)
+}
+
+func EnterKeyHint(v string) H {
+	return Attr("enterkeyhint", v)
+}
+
+func Headers(v string) H {
+	return Attr("headers", v)
+}
+
+func High(v string) H {
+	return Attr("high", v)
+}
+
+func HrefLang(v string) H {
+	return Attr("hreflang", v)
+}
+
+func HTTPEquiv(v string) H {
+	return Attr("http-equiv", v)
+}
+
+func InputMode(v string) H {
+	return Attr("inputmode", v)
+}
+
+func Is(v string) H {
+	return Attr("is", v)
+}
+
+func Kind(v string) H {
+	return Attr("kind", v)
+}
+
+func Low(v string) H {
+	return Attr("low", v)
+}
+
+func Media(v string) H {
+	return Attr("media", v)
+}
+
+func Nonce(v string) H {
+	return Attr("nonce", v)
+}
+
+func Optimum(v string) H {
+	return Attr("optimum", v)
+}
+
+func Part(v string) H {
+	return Attr("part", v)
+}
+
+func Ping(v string) H {
+	return Attr("ping", v)
+}
+
+func Sandbox(v string) H {
+	return Attr("sandbox", v)
+}
+
+func Shape(v string) H {
+	return Attr("shape", v)
+}
+
+func Size(v string) H {
+	return Attr("size", v)
+}
+
+func Sizes(v string) H {
+	return Attr("sizes", v)
+}
+
+func Slot(v string) H {
+	return Attr("slot", v)
+}
+
+func SpanAttr(v string) H {
+	return Attr("span", v)
+}
+
+func SpellCheck(v string) H {
+	return Attr("spellcheck", v)
+}
+
+func SrcDoc(v string) H {
+	return Attr("srcdoc", v)
+}
+
+func SrcLang(v string) H {
+	return Attr("srclang", v)
+}
+
+func Start(v string) H {
+	return Attr("start", v)
+}
+
+func Translate(v string) H {
+	return Attr("translate", v)
+}
+
+func UseMap(v string) H {
+	return Attr("usemap", v)
+}
+
+func Wrap(v string) H {
+	return Attr("wrap", v)
+}
+
+// Boolean attributes. Use them with If to set them conditionally, e.g.
+// h.If(done, h.Checked()).
+
+func Async() H {
+	return gh.Async()
+}
+
+func AutoFocus() H {
+	return gh.AutoFocus()
+}
+
+func AutoPlay() H {
+	return gh.AutoPlay()
+}
+
+func Checked() H {
+	return gh.Checked()
+}
+
+func Controls() H {
+	return gh.Controls()
+}
+
+func Defer() H {
+	return gh.Defer()
+}
+
+func Disabled() H {
+	return gh.Disabled()
+}
+
+func FormNoValidate() H {
+	return gh.FormNoValidate()
+}
+
+func Loop() H {
+	return gh.Loop()
+}
+
+func Multiple() H {
+	return gh.Multiple()
+}
+
+func Muted() H {
+	return gh.Muted()
+}
+
+func PlaysInline() H {
+	return gh.PlaysInline()
+}
+
+func ReadOnly() H {
+	return gh.ReadOnly()
+}
+
+func Required() H {
+	return gh.Required()
+}
+
+func Selected() H {
+	return gh.Selected()
+}
+
+func AllowFullscreen() H {
+	return Attr("allowfullscreen")
+}
+
+func Default() H {
+	return Attr("default")
+}
+
+func Hidden() H {
+	return Attr("hidden")
+}
+
+func Inert() H {
+	return Attr("inert")
+}
+
+func IsMap() H {
+	return Attr("ismap")
+}
+
+func ItemScope() H {
+	return Attr("itemscope")
+}
+
+func NoModule() H {
+	return Attr("nomodule")
+}
+
+func NoValidate() H {
+	return Attr("novalidate")
+}
+
+func Open() H {
+	return Attr("open")
+}
+
+func Reversed() H {
+	return Attr("reversed")
+}
+
+// Popover makes the element a popover, "auto" unless a value such as
+// "manual" is given.
+func Popover(value ...string) H {
+	return gh.Popover(value...)
+}
+
 // Data attributes automatically have their name prefixed with "data-".
 func Data(name, v string) H {
 	return gh.Data(name, v)
 }
+
+// Aria attributes automatically have their name prefixed with "aria-".
+func Aria(name, v string) H {
+	return gh.Aria(name, v)
+}
+
+func AriaActiveDescendant(v string) H {
+	return Aria("activedescendant", v)
+}
+
+func AriaAutoComplete(v string) H {
+	return Aria("autocomplete", v)
+}
+
+func AriaChecked(v string) H {
+	return Aria("checked", v)
+}
+
+func AriaColCount(v string) H {
+	return Aria("colcount", v)
+}
+
+func AriaColIndex(v string) H {
+	return Aria("colindex", v)
+}
+
+func AriaControls(v string) H {
+	return Aria("controls", v)
+}
+
+func AriaCurrent(v string) H {
+	return Aria("current", v)
+}
+
+func AriaDescribedBy(v string) H {
+	return Aria("describedby", v)
+}
+
+func AriaDescription(v string) H {
+	return Aria("description", v)
+}
+
+func AriaDetails(v string) H {
+	return Aria("details", v)
+}
+
+func AriaErrorMessage(v string) H {
+	return Aria("errormessage", v)
+}
+
+func AriaHasPopup(v string) H {
+	return Aria("haspopup", v)
+}
+
+func AriaInvalid(v string) H {
+	return Aria("invalid", v)
+}
+
+func AriaKeyShortcuts(v string) H {
+	return Aria("keyshortcuts", v)
+}
+
+func AriaLabel(v string) H {
+	return Aria("label", v)
+}
+
+func AriaLabelledBy(v string) H {
+	return Aria("labelledby", v)
+}
+
+func AriaLevel(v string) H {
+	return Aria("level", v)
+}
+
+func AriaLive(v string) H {
+	return Aria("live", v)
+}
+
+func AriaOrientation(v string) H {
+	return Aria("orientation", v)
+}
+
+func AriaOwns(v string) H {
+	return Aria("owns", v)
+}
+
+func AriaPosInSet(v string) H {
+	return Aria("posinset", v)
+}
+
+func AriaPressed(v string) H {
+	return Aria("pressed", v)
+}
+
+func AriaRelevant(v string) H {
+	return Aria("relevant", v)
+}
+
+func AriaRoleDescription(v string) H {
+	return Aria("roledescription", v)
+}
+
+func AriaRowCount(v string) H {
+	return Aria("rowcount", v)
+}
+
+func AriaRowIndex(v string) H {
+	return Aria("rowindex", v)
+}
+
+func AriaSetSize(v string) H {
+	return Aria("setsize", v)
+}
+
+func AriaSort(v string) H {
+	return Aria("sort", v)
+}
+
+func AriaValueMax(v string) H {
+	return Aria("valuemax", v)
+}
+
+func AriaValueMin(v string) H {
+	return Aria("valuemin", v)
+}
+
+func AriaValueNow(v string) H {
+	return Aria("valuenow", v)
+}
+
+func AriaValueText(v string) H {
+	return Aria("valuetext", v)
+}
+
+// ARIA states that are either "true" or "false".
+
+func AriaAtomic(v bool) H {
+	return Aria("atomic", strconv.FormatBool(v))
+}
+
+func AriaBusy(v bool) H {
+	return Aria("busy", strconv.FormatBool(v))
+}
+
+func AriaDisabled(v bool) H {
+	return Aria("disabled", strconv.FormatBool(v))
+}
+
+func AriaExpanded(v bool) H {
+	return Aria("expanded", strconv.FormatBool(v))
+}
+
+func AriaHidden(v bool) H {
+	return Aria("hidden", strconv.FormatBool(v))
+}
+
+func AriaModal(v bool) H {
+	return Aria("modal", strconv.FormatBool(v))
+}
+
+func AriaMultiLine(v bool) H {
+	return Aria("multiline", strconv.FormatBool(v))
+}
+
+func AriaMultiSelectable(v bool) H {
+	return Aria("multiselectable", strconv.FormatBool(v))
+}
+
+func AriaReadOnly(v bool) H {
+	return Aria("readonly", strconv.FormatBool(v))
+}
+
+func AriaRequired(v bool) H {
+	return Aria("required", strconv.FormatBool(v))
+}
+
+func AriaSelected(v bool) H {
+	return Aria("selected", strconv.FormatBool(v))
+}
+
+// ClassIf sets class name if condition is true. For several conditional
+// classes on one element use Classes, as browsers ignore repeated class
+// attributes.
+func ClassIf(condition bool, name string) H {
+	return If(condition, Class(name))
+}
+
+// Classes renders a class attribute with the names mapped to true, sorted.
+//
+// Example:
+//
+//	h.Li(h.Classes(map[string]bool{"item": true, "done": t.Done, "active": t.ID == selected}))
+func Classes(names map[string]bool) H {
+	return gc.Classes(names)
+}
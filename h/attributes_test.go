@@ -0,0 +1,223 @@
+package h
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func render(t *testing.T, n H) string {
+	t.Helper()
+	var b strings.Builder
+	if err := n.Render(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		attr H
+		want string
+	}{
+		{Accept("x"), ` accept="x"`},
+		{Action("x"), ` action="x"`},
+		{Alt("x"), ` alt="x"`},
+		{As("x"), ` as="x"`},
+		{AutoComplete("x"), ` autocomplete="x"`},
+		{Charset("x"), ` charset="x"`},
+		{CiteAttr("x"), ` cite="x"`},
+		{Class("x"), ` class="x"`},
+		{Cols("x"), ` cols="x"`},
+		{ColSpan("x"), ` colspan="x"`},
+		{Content("x"), ` content="x"`},
+		{CrossOrigin("x"), ` crossorigin="x"`},
+		{DateTime("x"), ` datetime="x"`},
+		{Dir("x"), ` dir="x"`},
+		{Download("x"), ` download="x"`},
+		{Draggable("x"), ` draggable="x"`},
+		{EncType("x"), ` enctype="x"`},
+		{For("x"), ` for="x"`},
+		{FormAction("x"), ` formaction="x"`},
+		{FormAttr("x"), ` form="x"`},
+		{FormEncType("x"), ` formenctype="x"`},
+		{FormMethod("x"), ` formmethod="x"`},
+		{FormTarget("x"), ` formtarget="x"`},
+		{Height("x"), ` height="x"`},
+		{Href("x"), ` href="x"`},
+		{ID("x"), ` id="x"`},
+		{Integrity("x"), ` integrity="x"`},
+		{LabelAttr("x"), ` label="x"`},
+		{Lang("x"), ` lang="x"`},
+		{List("x"), ` list="x"`},
+		{Loading("x"), ` loading="x"`},
+		{Max("x"), ` max="x"`},
+		{MaxLength("x"), ` maxlength="x"`},
+		{Method("x"), ` method="x"`},
+		{Min("x"), ` min="x"`},
+		{MinLength("x"), ` minlength="x"`},
+		{Name("x"), ` name="x"`},
+		{Pattern("x"), ` pattern="x"`},
+		{Placeholder("x"), ` placeholder="x"`},
+		{PopoverTarget("x"), ` popovertarget="x"`},
+		{PopoverTargetAction("x"), ` popovertargetaction="x"`},
+		{Poster("x"), ` poster="x"`},
+		{Preload("x"), ` preload="x"`},
+		{ReferrerPolicy("x"), ` referrerpolicy="x"`},
+		{Rel("x"), ` rel="x"`},
+		{Role("x"), ` role="x"`},
+		{Rows("x"), ` rows="x"`},
+		{RowSpan("x"), ` rowspan="x"`},
+		{Scope("x"), ` scope="x"`},
+		{Src("x"), ` src="x"`},
+		{SrcSet("x"), ` srcset="x"`},
+		{Step("x"), ` step="x"`},
+		{TabIndex("x"), ` tabindex="x"`},
+		{Target("x"), ` target="x"`},
+		{Title("x"), ` title="x"`},
+		{Type("x"), ` type="x"`},
+		{Value("x"), ` value="x"`},
+		{Width("x"), ` width="x"`},
+		{AccessKey("x"), ` accesskey="x"`},
+		{Allow("x"), ` allow="x"`},
+		{AutoCapitalize("x"), ` autocapitalize="x"`},
+		{Capture("x"), ` capture="x"`},
+		{ContentEditable("x"), ` contenteditable="x"`},
+		{Coords("x"), ` coords="x"`},
+		{DirName("x"), ` dirname="x"`},
+		{EnterKeyHint("x"), ` enterkeyhint="x"`},
+		{Headers("x"), ` headers="x"`},
+		{High("x"), ` high="x"`},
+		{HrefLang("x"), ` hreflang="x"`},
+		{HTTPEquiv("x"), ` http-equiv="x"`},
+		{InputMode("x"), ` inputmode="x"`},
+		{Is("x"), ` is="x"`},
+		{Kind("x"), ` kind="x"`},
+		{Low("x"), ` low="x"`},
+		{Media("x"), ` media="x"`},
+		{Nonce("x"), ` nonce="x"`},
+		{Optimum("x"), ` optimum="x"`},
+		{Part("x"), ` part="x"`},
+		{Ping("x"), ` ping="x"`},
+		{Sandbox("x"), ` sandbox="x"`},
+		{Shape("x"), ` shape="x"`},
+		{Size("x"), ` size="x"`},
+		{Sizes("x"), ` sizes="x"`},
+		{Slot("x"), ` slot="x"`},
+		{SpanAttr("x"), ` span="x"`},
+		{SpellCheck("x"), ` spellcheck="x"`},
+		{SrcDoc("x"), ` srcdoc="x"`},
+		{SrcLang("x"), ` srclang="x"`},
+		{Start("x"), ` start="x"`},
+		{Translate("x"), ` translate="x"`},
+		{UseMap("x"), ` usemap="x"`},
+		{Wrap("x"), ` wrap="x"`},
+		{Async(), ` async`},
+		{AutoFocus(), ` autofocus`},
+		{AutoPlay(), ` autoplay`},
+		{Checked(), ` checked`},
+		{Controls(), ` controls`},
+		{Defer(), ` defer`},
+		{Disabled(), ` disabled`},
+		{FormNoValidate(), ` formnovalidate`},
+		{Loop(), ` loop`},
+		{Multiple(), ` multiple`},
+		{Muted(), ` muted`},
+		{PlaysInline(), ` playsinline`},
+		{ReadOnly(), ` readonly`},
+		{Required(), ` required`},
+		{Selected(), ` selected`},
+		{AllowFullscreen(), ` allowfullscreen`},
+		{Default(), ` default`},
+		{Hidden(), ` hidden`},
+		{Inert(), ` inert`},
+		{IsMap(), ` ismap`},
+		{ItemScope(), ` itemscope`},
+		{NoModule(), ` nomodule`},
+		{NoValidate(), ` novalidate`},
+		{Open(), ` open`},
+		{Reversed(), ` reversed`},
+		{AriaActiveDescendant("x"), ` aria-activedescendant="x"`},
+		{AriaAutoComplete("x"), ` aria-autocomplete="x"`},
+		{AriaChecked("x"), ` aria-checked="x"`},
+		{AriaColCount("x"), ` aria-colcount="x"`},
+		{AriaColIndex("x"), ` aria-colindex="x"`},
+		{AriaControls("x"), ` aria-controls="x"`},
+		{AriaCurrent("x"), ` aria-current="x"`},
+		{AriaDescribedBy("x"), ` aria-describedby="x"`},
+		{AriaDescription("x"), ` aria-description="x"`},
+		{AriaDetails("x"), ` aria-details="x"`},
+		{AriaErrorMessage("x"), ` aria-errormessage="x"`},
+		{AriaHasPopup("x"), ` aria-haspopup="x"`},
+		{AriaInvalid("x"), ` aria-invalid="x"`},
+		{AriaKeyShortcuts("x"), ` aria-keyshortcuts="x"`},
+		{AriaLabel("x"), ` aria-label="x"`},
+		{AriaLabelledBy("x"), ` aria-labelledby="x"`},
+		{AriaLevel("x"), ` aria-level="x"`},
+		{AriaLive("x"), ` aria-live="x"`},
+		{AriaOrientation("x"), ` aria-orientation="x"`},
+		{AriaOwns("x"), ` aria-owns="x"`},
+		{AriaPosInSet("x"), ` aria-posinset="x"`},
+		{AriaPressed("x"), ` aria-pressed="x"`},
+		{AriaRelevant("x"), ` aria-relevant="x"`},
+		{AriaRoleDescription("x"), ` aria-roledescription="x"`},
+		{AriaRowCount("x"), ` aria-rowcount="x"`},
+		{AriaRowIndex("x"), ` aria-rowindex="x"`},
+		{AriaSetSize("x"), ` aria-setsize="x"`},
+		{AriaSort("x"), ` aria-sort="x"`},
+		{AriaValueMax("x"), ` aria-valuemax="x"`},
+		{AriaValueMin("x"), ` aria-valuemin="x"`},
+		{AriaValueNow("x"), ` aria-valuenow="x"`},
+		{AriaValueText("x"), ` aria-valuetext="x"`},
+		{AriaAtomic(true), ` aria-atomic="true"`},
+		{AriaAtomic(false), ` aria-atomic="false"`},
+		{AriaBusy(true), ` aria-busy="true"`},
+		{AriaBusy(false), ` aria-busy="false"`},
+		{AriaDisabled(true), ` aria-disabled="true"`},
+		{AriaDisabled(false), ` aria-disabled="false"`},
+		{AriaExpanded(true), ` aria-expanded="true"`},
+		{AriaExpanded(false), ` aria-expanded="false"`},
+		{AriaHidden(true), ` aria-hidden="true"`},
+		{AriaHidden(false), ` aria-hidden="false"`},
+		{AriaModal(true), ` aria-modal="true"`},
+		{AriaModal(false), ` aria-modal="false"`},
+		{AriaMultiLine(true), ` aria-multiline="true"`},
+		{AriaMultiLine(false), ` aria-multiline="false"`},
+		{AriaMultiSelectable(true), ` aria-multiselectable="true"`},
+		{AriaMultiSelectable(false), ` aria-multiselectable="false"`},
+		{AriaReadOnly(true), ` aria-readonly="true"`},
+		{AriaReadOnly(false), ` aria-readonly="false"`},
+		{AriaRequired(true), ` aria-required="true"`},
+		{AriaRequired(false), ` aria-required="false"`},
+		{AriaSelected(true), ` aria-selected="true"`},
+		{AriaSelected(false), ` aria-selected="false"`},
+		{Data("id", "x"), ` data-id="x"`},
+		{Aria("label", "x"), ` aria-label="x"`},
+		{Popover(), ` popover`},
+		{Popover("manual"), ` popover="manual"`},
+	} {
+		assert.Equal(t, tc.want, render(t, tc.attr))
+	}
+}
+
+func TestAttributes_EscapeValues(t *testing.T) {
+	assert.Equal(t, `<a title="&#34;&lt;x&gt;&#34;"></a>`, render(t, A(Title(`"<x>"`))))
+}
+
+func TestBooleanAttributes_OnElements(t *testing.T) {
+	assert.Equal(t, `<input type="checkbox" name="done" checked disabled>`,
+		render(t, Input(Type("checkbox"), Name("done"), If(true, Checked()), Disabled(), If(false, Required()))))
+	assert.Equal(t, `<label for="email">Email</label>`, render(t, Label(For("email"), Text("Email"))))
+}
+
+func TestClassIf(t *testing.T) {
+	assert.Equal(t, `<li class="done"></li>`, render(t, Li(ClassIf(true, "done"))))
+	assert.Equal(t, `<li></li>`, render(t, Li(ClassIf(false, "done"))))
+}
+
+func TestClasses(t *testing.T) {
+	assert.Equal(t, `<li class="active item"></li>`,
+		render(t, Li(Classes(map[string]bool{"item": true, "done": false, "active": true}))))
+	assert.Equal(t, ` class=""`, render(t, Classes(nil)))
+}
@@ -0,0 +1,128 @@
+package h
+
+import (
+	"io"
+	"iter"
+	"strings"
+
+	g "maragu.dev/gomponents"
+)
+
+// Group renders children in place without a wrapping element. Attributes in
+// a group apply to the element the group is placed in.
+//
+// Example:
+//
+//	h.Ul(h.Group(h.Li(h.Text("a")), h.Li(h.Text("b"))))
+func Group(children ...H) H {
+	return g.Group(retype(children))
+}
+
+// Map renders fn for each item, in order.
+//
+// Example:
+//
+//	h.Ul(h.Map(users, func(u User) h.H { return h.Li(h.Text(u.Name)) }))
+func Map[T any](items []T, fn func(T) H) H {
+	nodes := make([]H, len(items))
+	for i, item := range items {
+		nodes[i] = fn(item)
+	}
+	return Group(nodes...)
+}
+
+// MapIndexed renders fn for each item with its index, in order.
+func MapIndexed[T any](items []T, fn func(int, T) H) H {
+	nodes := make([]H, len(items))
+	for i, item := range items {
+		nodes[i] = fn(i, item)
+	}
+	return Group(nodes...)
+}
+
+// Each renders fn for each item of seq as the item is produced, without
+// holding the list in memory, e.g. to stream the rows of a large report
+// from a database cursor. seq is consumed each time the node renders.
+//
+// Example:
+//
+//	h.TBody(h.Each(rowsSeq(rows), func(r Row) h.H { return h.Tr(h.Td(h.Text(r.Name))) }))
+func Each[T any](seq iter.Seq[T], fn func(T) H) H {
+	return nodeFunc(func(w io.Writer) error {
+		for item := range seq {
+			if n := fn(item); n != nil {
+				if err := n.Render(w); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// IfElse renders n if condition is true and otherwise els.
+func IfElse(condition bool, n, els H) H {
+	if condition {
+		return n
+	}
+	return els
+}
+
+// SwitchCase is a branch of Switch.
+type SwitchCase struct {
+	ok bool
+	n  H
+}
+
+// Case is a branch of Switch taken if condition is true.
+func Case(condition bool, n H) SwitchCase {
+	return SwitchCase{ok: condition, n: n}
+}
+
+// Otherwise is a branch of Switch that is always taken; place it last.
+func Otherwise(n H) SwitchCase {
+	return SwitchCase{ok: true, n: n}
+}
+
+// Switch renders the first case that is taken, or nothing.
+//
+// Example:
+//
+//	h.Switch(
+//		h.Case(order.Status == "paid", h.Text("Paid")),
+//		h.Case(order.Status == "sent", h.Text("On its way")),
+//		h.Otherwise(h.Text("Pending")),
+//	)
+func Switch(cases ...SwitchCase) H {
+	for _, c := range cases {
+		if c.ok {
+			return c.n
+		}
+	}
+	return nil
+}
+
+// KeyID returns the element id Keyed gives the item with key in the list
+// prefix. Use it to patch a single item with Context.SyncElements.
+func KeyID(prefix, key string) string {
+	return prefix + "-" + strings.Join(strings.Fields(key), "_")
+}
+
+// Keyed renders a list whose items keep their element id across renders, so
+// patches morph each item in place and an input being edited in a long list
+// keeps its focus and value when items are added, removed or reordered.
+//
+// render receives the id attribute of the item and must put it on the
+// item's top element. Keys must be unique within the list.
+//
+// Example:
+//
+//	h.Ul(h.Keyed("todo", todos, func(t Todo) string { return t.ID },
+//		func(id h.H, t Todo) h.H { return h.Li(id, h.Input(h.Value(t.Title))) }))
+func Keyed[T any](prefix string, items []T, key func(T) string, render func(id H, item T) H) H {
+	nodes := make([]H, len(items))
+	for i, item := range items {
+		nodes[i] = render(ID(KeyID(prefix, key(item))), item)
+	}
+	return Group(nodes...)
+}
@@ -0,0 +1,83 @@
+package h
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	assert.Equal(t, `<ul class="x"><li>a</li><li>b</li></ul>`,
+		render(t, Ul(Group(Class("x"), Li(Text("a"))), Group(Li(Text("b")), nil))))
+	assert.Equal(t, ``, render(t, Group()))
+}
+
+func TestMap(t *testing.T) {
+	items := []string{"a", "<b>"}
+	assert.Equal(t, `<ul><li>a</li><li>&lt;b&gt;</li></ul>`,
+		render(t, Ul(Map(items, func(s string) H { return Li(Text(s)) }))))
+	assert.Equal(t, `<ol><li>0:a</li><li>1:&lt;b&gt;</li></ol>`,
+		render(t, Ol(MapIndexed(items, func(i int, s string) H { return Li(Textf("%d:%s", i, s)) }))))
+	assert.Equal(t, `<ul></ul>`, render(t, Ul(Map([]int(nil), func(int) H { return Li() }))))
+}
+
+func TestIfElse(t *testing.T) {
+	assert.Equal(t, `yes`, render(t, IfElse(true, Text("yes"), Text("no"))))
+	assert.Equal(t, `no`, render(t, IfElse(false, Text("yes"), Text("no"))))
+}
+
+func TestSwitch(t *testing.T) {
+	status := func(s string) H {
+		return Switch(
+			Case(s == "paid", Text("Paid")),
+			Case(s == "sent", Text("On its way")),
+			Otherwise(Text("Pending")),
+		)
+	}
+	assert.Equal(t, `Paid`, render(t, status("paid")))
+	assert.Equal(t, `On its way`, render(t, status("sent")))
+	assert.Equal(t, `Pending`, render(t, status("new")))
+	assert.Nil(t, Switch(Case(false, Text("x"))))
+}
+
+func TestKeyed(t *testing.T) {
+	type todo struct{ id, title string }
+	list := func(todos []todo) string {
+		return render(t, Ul(Keyed("todo", todos, func(t todo) string { return t.id },
+			func(id H, t todo) H { return Li(id, Input(Value(t.title))) })))
+	}
+	a, b := todo{"1", "milk"}, todo{"2 x", "eggs"}
+	assert.Equal(t, `<ul><li id="todo-1"><input value="milk"></li><li id="todo-2_x"><input value="eggs"></li></ul>`, list([]todo{a, b}))
+	assert.Equal(t, `<ul><li id="todo-2_x"><input value="eggs"></li><li id="todo-1"><input value="milk"></li></ul>`, list([]todo{b, a}),
+		"ids follow the items, not their position")
+	assert.Equal(t, "todo-2_x", KeyID("todo", "2 x"))
+}
+
+func TestEach(t *testing.T) {
+	seq := func(yield func(int) bool) {
+		for i := range 3 {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	assert.Equal(t, `<ul><li>0</li><li>2</li></ul>`, render(t, Ul(Each(seq, func(i int) H {
+		return If(i%2 == 0, Li(Textf("%d", i)))
+	}))))
+
+	var yielded int
+	counted := func(yield func(int) bool) {
+		for i := range 3 {
+			yielded++
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	var b strings.Builder
+	err := Each(counted, func(i int) H { return HTMLTemplate(template.New("empty"), "missing", nil) }).Render(&b)
+	assert.Error(t, err)
+	assert.Equal(t, 1, yielded, "rendering stops at the first error")
+}
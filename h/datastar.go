@@ -1,13 +1,378 @@
 package h
 
-func DataInit(expression string) H {
-	return Data("init", expression)
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is a Datastar expression, the JavaScript run by data-* attributes.
+// Build one from signals with Signal or a via signal's Expr method, from Go
+// values with Lit, or convert a string for anything else.
+//
+// Example:
+//
+//	h.DataShow(h.And(open.Expr(), h.Not(h.Signal("busy"))))
+type Expr string
+
+// String returns the expression source.
+func (e Expr) String() string {
+	return string(e)
+}
+
+// Signal references the signal name, e.g. $count.
+func Signal(name string) Expr {
+	return Expr("$" + name)
+}
+
+// Lit renders v as a JavaScript literal. Strings are quoted and escaped, so
+// user input can not break out of the expression.
+func Lit(v any) Expr {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "null"
+	}
+	return Expr(b)
+}
+
+// Not negates e.
+func Not(e Expr) Expr {
+	return "!(" + e + ")"
+}
+
+// And is true if all of es are.
+func And(es ...Expr) Expr {
+	return join(es, " && ")
+}
+
+// Or is true if any of es is.
+func Or(es ...Expr) Expr {
+	return join(es, " || ")
+}
+
+// Seq runs es in order, e.g. to set a signal before calling an action.
+func Seq(es ...Expr) Expr {
+	var b strings.Builder
+	for i, e := range es {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(string(e))
+	}
+	return Expr(b.String())
+}
+
+func join(es []Expr, op string) Expr {
+	if len(es) == 1 {
+		return es[0]
+	}
+	var b strings.Builder
+	for i, e := range es {
+		if i > 0 {
+			b.WriteString(op)
+		}
+		b.WriteString("(" + string(e) + ")")
+	}
+	return Expr(b.String())
+}
+
+// Modifier changes how a Datastar attribute behaves, rendered after the
+// attribute key as __name.args, e.g. data-on:input__debounce.500ms.
+type Modifier string
+
+// Modifiers without arguments. Which attributes accept them is listed in
+// the Datastar reference; attributes ignore modifiers they do not know.
+const (
+	ModOnce           Modifier = "once"           // data-on, data-on-intersect
+	ModPassive        Modifier = "passive"        // data-on
+	ModCapture        Modifier = "capture"        // data-on
+	ModWindow         Modifier = "window"         // data-on
+	ModOutside        Modifier = "outside"        // data-on
+	ModPrevent        Modifier = "prevent"        // data-on
+	ModStop           Modifier = "stop"           // data-on
+	ModViewTransition Modifier = "viewtransition" // data-on*, data-init
+	ModHalf           Modifier = "half"           // data-on-intersect
+	ModFull           Modifier = "full"           // data-on-intersect
+	ModIfMissing      Modifier = "ifmissing"      // data-signals
+	ModTerse          Modifier = "terse"          // data-json-signals
+	ModSelf           Modifier = "self"           // data-ignore
+
+	// Key casing of data-bind, data-class, data-computed, data-indicator,
+	// data-on, data-ref and data-signals.
+	ModCamel  Modifier = "case.camel"
+	ModKebab  Modifier = "case.kebab"
+	ModSnake  Modifier = "case.snake"
+	ModPascal Modifier = "case.pascal"
+)
+
+// ModDebounce runs the expression once events stop for d. Add Leading or
+// NoTrailing to change which edge fires.
+func ModDebounce(d time.Duration) Modifier {
+	return Modifier("debounce." + ms(d))
+}
+
+// ModThrottle runs the expression at most once per d. Add NoLeading or
+// Trailing to change which edge fires.
+func ModThrottle(d time.Duration) Modifier {
+	return Modifier("throttle." + ms(d))
 }
 
-func DataEffect(expression string) H {
-	return Data("effect", expression)
+// ModDelay waits d before running the expression.
+func ModDelay(d time.Duration) Modifier {
+	return Modifier("delay." + ms(d))
 }
 
+// ModDuration sets the period of data-on-interval, one second by default.
+// Add Leading to also run it right away.
+func ModDuration(d time.Duration) Modifier {
+	return Modifier("duration." + ms(d))
+}
+
+// Leading fires a debounce, or an interval, on the leading edge.
+func (m Modifier) Leading() Modifier { return m + ".leading" }
+
+// NoLeading keeps a throttle from firing on the leading edge.
+func (m Modifier) NoLeading() Modifier { return m + ".noleading" }
+
+// Trailing fires a throttle on the trailing edge too.
+func (m Modifier) Trailing() Modifier { return m + ".trailing" }
+
+// NoTrailing keeps a debounce from firing on the trailing edge.
+func (m Modifier) NoTrailing() Modifier { return m + ".notrailing" }
+
+func ms(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// dataAttr renders data-name, with its key and modifiers, set to value.
+func dataAttr(name string, value Expr, mods []Modifier) H {
+	for _, m := range mods {
+		name += "__" + string(m)
+	}
+	return Data(name, string(value))
+}
+
+// signalKey writes a signal name as an attribute key. HTML lowercases
+// attribute names and Datastar camel-cases keys by default, so fooBar is
+// written foo-bar to arrive as fooBar.
+func signalKey(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('-')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var keyEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`)
+
+// object renders exprs as a JavaScript object with quoted keys.
+func object(exprs map[string]Expr) Expr {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range slices.Sorted(maps.Keys(exprs)) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("'" + keyEscaper.Replace(k) + "': " + string(exprs[k]))
+	}
+	b.WriteByte('}')
+	return Expr(b.String())
+}
+
+// DataInit runs expression when the element is loaded.
+func DataInit(expression Expr, mods ...Modifier) H {
+	return dataAttr("init", expression, mods)
+}
+
+// DataEffect runs expression whenever a signal it reads changes.
+func DataEffect(expression Expr) H {
+	return Data("effect", string(expression))
+}
+
+// DataIgnoreMorph keeps patches from changing the element and its children.
 func DataIgnoreMorph() H {
 	return Attr("data-ignore-morph")
 }
+
+// DataIgnore keeps Datastar from processing the element and its children,
+// or with ModSelf only the element.
+func DataIgnore(mods ...Modifier) H {
+	if len(mods) == 0 {
+		return Attr("data-ignore")
+	}
+	return dataAttr("ignore", "", mods)
+}
+
+// DataPreserveAttr keeps the client-side value of the named attributes when
+// the element is patched, e.g. "open" on details.
+func DataPreserveAttr(names ...string) H {
+	return Data("preserve-attr", strings.Join(names, " "))
+}
+
+// DataShow shows the element while expression is true.
+func DataShow(expression Expr) H {
+	return Data("show", string(expression))
+}
+
+// DataText sets the text content of the element to expression.
+func DataText(expression Expr) H {
+	return Data("text", string(expression))
+}
+
+// DataClass adds class while expression is true.
+//
+// HTML lowercases attribute names, so a class with upper-case letters
+// renders in the object form of DataClasses. mods are kept there, but
+// Datastar applies case modifiers to the name form only.
+func DataClass(class string, expression Expr, mods ...Modifier) H {
+	if strings.ToLower(class) != class {
+		return DataClasses(map[string]Expr{class: expression}, mods...)
+	}
+	return dataAttr("class:"+class, expression, mods)
+}
+
+// DataClasses adds each class while its expression is true. Class names
+// are used as written; case modifiers do not apply to them.
+//
+// Example:
+//
+//	h.DataClasses(map[string]h.Expr{"active": h.Signal("on"), "text-muted": h.Not(h.Signal("on"))})
+func DataClasses(classes map[string]Expr, mods ...Modifier) H {
+	return dataAttr("class", object(classes), mods)
+}
+
+// DataAttr sets attribute name to expression; false, null and undefined
+// remove it.
+//
+// Datastar sets the attribute named in data-attr:name as written, but HTML
+// lowercases attribute names, so camelCase names such as viewBox render in
+// the object form data-attr="{'viewBox': expr}". An element takes one object
+// form; set several camelCase attributes with DataAttrs.
+func DataAttr(name string, expression Expr) H {
+	if strings.ToLower(name) != name {
+		return DataAttrs(map[string]Expr{name: expression})
+	}
+	return Data("attr:"+name, string(expression))
+}
+
+// DataAttrs sets several attributes in one data-attr attribute, keeping the
+// case of their names.
+func DataAttrs(attrs map[string]Expr) H {
+	return Data("attr", string(object(attrs)))
+}
+
+// DataStyle sets the CSS property to expression.
+func DataStyle(property string, expression Expr) H {
+	return Data("style:"+property, string(expression))
+}
+
+// DataStyles sets several CSS properties in one data-style attribute.
+func DataStyles(properties map[string]Expr) H {
+	return Data("style", string(object(properties)))
+}
+
+// DataBind binds the value of a form element to signal, creating it if
+// needed.
+func DataBind(signal string, mods ...Modifier) H {
+	return dataAttr("bind", Expr(signal), mods)
+}
+
+// DataRef stores the element in signal.
+func DataRef(signal string, mods ...Modifier) H {
+	return dataAttr("ref", Expr(signal), mods)
+}
+
+// DataIndicator sets signal to true while a request started by the element
+// is running.
+func DataIndicator(signal string, mods ...Modifier) H {
+	return dataAttr("indicator", Expr(signal), mods)
+}
+
+// DataSignal creates signal name with the value of expression.
+func DataSignal(name string, expression Expr, mods ...Modifier) H {
+	return dataAttr("signals:"+signalKey(name), expression, mods)
+}
+
+// DataSignals creates signals from the JSON encoding of values.
+//
+// Example:
+//
+//	h.DataSignals(map[string]any{"query": "", "page": 1}, h.ModIfMissing)
+func DataSignals(values any, mods ...Modifier) H {
+	return dataAttr("signals", Lit(values), mods)
+}
+
+// DataComputed creates the read-only signal name, derived from expression.
+func DataComputed(name string, expression Expr, mods ...Modifier) H {
+	return dataAttr("computed:"+signalKey(name), expression, mods)
+}
+
+// DataOn runs expression on event. The event is available as evt.
+//
+// Example:
+//
+//	h.DataOn("input", h.Expr("@get('/search')"), h.ModDebounce(300*time.Millisecond))
+func DataOn(event string, expression Expr, mods ...Modifier) H {
+	return dataAttr("on:"+event, expression, mods)
+}
+
+// DataOnIntersect runs expression when the element enters the viewport.
+func DataOnIntersect(expression Expr, mods ...Modifier) H {
+	return dataAttr("on-intersect", expression, mods)
+}
+
+// DataOnInterval runs expression periodically, every second unless
+// ModDuration says otherwise.
+func DataOnInterval(expression Expr, mods ...Modifier) H {
+	return dataAttr("on-interval", expression, mods)
+}
+
+// DataOnSignalPatch runs expression when signals change. The changes are
+// available as patch; narrow them with DataOnSignalPatchFilter.
+func DataOnSignalPatch(expression Expr, mods ...Modifier) H {
+	return dataAttr("on-signal-patch", expression, mods)
+}
+
+// SignalFilter selects signals by path, with JavaScript regular expressions.
+// An empty field matches every signal.
+type SignalFilter struct {
+	Include string
+	Exclude string
+}
+
+func (f SignalFilter) expr() Expr {
+	var parts []string
+	if f.Include != "" {
+		parts = append(parts, "include: "+jsRegexp(f.Include))
+	}
+	if f.Exclude != "" {
+		parts = append(parts, "exclude: "+jsRegexp(f.Exclude))
+	}
+	return Expr("{" + strings.Join(parts, ", ") + "}")
+}
+
+func jsRegexp(pattern string) string {
+	return "/" + strings.ReplaceAll(pattern, "/", `\/`) + "/"
+}
+
+// DataOnSignalPatchFilter limits the DataOnSignalPatch of the element to the
+// signals of f.
+func DataOnSignalPatchFilter(f SignalFilter) H {
+	return Data("on-signal-patch-filter", string(f.expr()))
+}
+
+// DataJSONSignals renders the signals of f as JSON text in the element, for
+// debugging.
+func DataJSONSignals(f SignalFilter, mods ...Modifier) H {
+	if f == (SignalFilter{}) {
+		return dataAttr("json-signals", "", mods)
+	}
+	return dataAttr("json-signals", f.expr(), mods)
+}
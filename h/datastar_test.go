@@ -0,0 +1,116 @@
+package h
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr(t *testing.T) {
+	assert.Equal(t, Expr("$count"), Signal("count"))
+	assert.Equal(t, Expr(`"it's \u003cb\u003e\"x\"\u003c/b\u003e"`), Lit(`it's <b>"x"</b>`))
+	assert.Equal(t, Expr("3"), Lit(3))
+	assert.Equal(t, Expr("null"), Lit(func() {}))
+	assert.Equal(t, Expr("!($a)"), Not(Signal("a")))
+	assert.Equal(t, Expr("($a) && (!($b))"), And(Signal("a"), Not(Signal("b"))))
+	assert.Equal(t, Expr("$a"), Or(Signal("a")))
+	assert.Equal(t, Expr("($a) || ($b > 1)"), Or(Signal("a"), "$b > 1"))
+	assert.Equal(t, Expr("$a = 1; @get('/x')"), Seq("$a = 1", "@get('/x')"))
+}
+
+func TestModifiers(t *testing.T) {
+	assert.Equal(t, Modifier("debounce.500ms"), ModDebounce(500*time.Millisecond))
+	assert.Equal(t, Modifier("debounce.1000ms.leading.notrailing"), ModDebounce(time.Second).Leading().NoTrailing())
+	assert.Equal(t, Modifier("throttle.250ms.noleading.trailing"), ModThrottle(250*time.Millisecond).NoLeading().Trailing())
+	assert.Equal(t, Modifier("delay.10ms"), ModDelay(10*time.Millisecond))
+	assert.Equal(t, Modifier("duration.2000ms.leading"), ModDuration(2*time.Second).Leading())
+}
+
+// datastarBuilders renders every builder; TestDatastarAttributes checks the
+// output and TestDatastarBundle that the embedded datastar.js knows it.
+var datastarBuilders = []struct {
+	attr H
+	want string
+}{
+	{DataInit("load()"), ` data-init="load()"`},
+	{DataInit("load()", ModDelay(time.Second), ModViewTransition), ` data-init__delay.1000ms__viewtransition="load()"`},
+	{DataEffect("$a = $b"), ` data-effect="$a = $b"`},
+	{DataIgnoreMorph(), ` data-ignore-morph`},
+	{DataIgnore(), ` data-ignore`},
+	{DataIgnore(ModSelf), ` data-ignore__self=""`},
+	{DataPreserveAttr("open", "class"), ` data-preserve-attr="open class"`},
+	{DataShow(Signal("open")), ` data-show="$open"`},
+	{DataText(Signal("name")), ` data-text="$name"`},
+	{DataClass("hidden", Not(Signal("open"))), ` data-class:hidden="!($open)"`},
+	{DataClass("is-open", Signal("open"), ModCamel), ` data-class:is-open__case.camel="$open"`},
+	{DataClass("isOpen", Signal("open")), ` data-class="{&#39;isOpen&#39;: $open}"`},
+	{DataClasses(map[string]Expr{"b": "$b", "a": "$a"}), ` data-class="{&#39;a&#39;: $a, &#39;b&#39;: $b}"`},
+	{DataClass("isOpen", Signal("open"), ModCamel), ` data-class__case.camel="{&#39;isOpen&#39;: $open}"`},
+	{DataClasses(map[string]Expr{`it's\`: "$a"}), ` data-class="{&#39;it\&#39;s\\&#39;: $a}"`},
+	{DataAttr("disabled", Signal("busy")), ` data-attr:disabled="$busy"`},
+	{DataAttr("viewBox", Signal("box")), ` data-attr="{&#39;viewBox&#39;: $box}"`},
+	{DataAttrs(map[string]Expr{"title": "$t"}), ` data-attr="{&#39;title&#39;: $t}"`},
+	{DataStyle("background-color", Signal("c")), ` data-style:background-color="$c"`},
+	{DataStyles(map[string]Expr{"display": "'none'"}), ` data-style="{&#39;display&#39;: &#39;none&#39;}"`},
+	{DataBind("query"), ` data-bind="query"`},
+	{DataBind("query", ModKebab), ` data-bind__case.kebab="query"`},
+	{DataRef("input"), ` data-ref="input"`},
+	{DataIndicator("loading", ModSnake), ` data-indicator__case.snake="loading"`},
+	{DataSignal("pageSize", Lit(10)), ` data-signals:page-size="10"`},
+	{DataSignal("x", "1", ModIfMissing, ModPascal), ` data-signals:x__ifmissing__case.pascal="1"`},
+	{DataSignals(map[string]any{"q": "", "n": 1}), ` data-signals="{&#34;n&#34;:1,&#34;q&#34;:&#34;&#34;}"`},
+	{DataComputed("fullName", "$first + ' ' + $last"), ` data-computed:full-name="$first + &#39; &#39; + $last"`},
+	{DataOn("click", "@get('/x')"), ` data-on:click="@get(&#39;/x&#39;)"`},
+	{DataOn("keydown", "go()", ModWindow, ModPrevent, ModStop), ` data-on:keydown__window__prevent__stop="go()"`},
+	{DataOn("click", "close()", ModOutside, ModOnce, ModCapture, ModPassive), ` data-on:click__outside__once__capture__passive="close()"`},
+	{DataOn("input", "s()", ModDebounce(500*time.Millisecond).Leading()), ` data-on:input__debounce.500ms.leading="s()"`},
+	{DataOn("scroll", "s()", ModThrottle(time.Second).Trailing()), ` data-on:scroll__throttle.1000ms.trailing="s()"`},
+	{DataOnIntersect("more()", ModOnce, ModHalf), ` data-on-intersect__once__half="more()"`},
+	{DataOnIntersect("seen()", ModFull), ` data-on-intersect__full="seen()"`},
+	{DataOnInterval("tick()", ModDuration(5*time.Second).Leading()), ` data-on-interval__duration.5000ms.leading="tick()"`},
+	{DataOnSignalPatch("save(patch)", ModDebounce(time.Second).NoTrailing()), ` data-on-signal-patch__debounce.1000ms.notrailing="save(patch)"`},
+	{DataOnSignalPatchFilter(SignalFilter{Include: "^form/", Exclude: "tmp$"}), ` data-on-signal-patch-filter="{include: /^form\//, exclude: /tmp$/}"`},
+	{DataJSONSignals(SignalFilter{}), ` data-json-signals=""`},
+	{DataJSONSignals(SignalFilter{Include: "user"}, ModTerse), ` data-json-signals__terse="{include: /user/}"`},
+}
+
+func TestDatastarAttributes(t *testing.T) {
+	for _, tc := range datastarBuilders {
+		assert.Equal(t, tc.want, render(t, tc.attr))
+	}
+}
+
+var datastarAttrRe = regexp.MustCompile(`^ data-([a-z-]+)(?::[a-z-]+)?((?:__[a-z.0-9]+)*)`)
+
+// TestDatastarBundle checks the attributes and modifiers the builders render
+// against the datastar.js that via serves.
+func TestDatastarBundle(t *testing.T) {
+	b, err := os.ReadFile("../datastar.js")
+	require.NoError(t, err)
+	js := string(b)
+	require.True(t, strings.HasPrefix(js, "// Datastar v1."), "builders target Datastar v1, not %.30q", js)
+
+	for _, tc := range datastarBuilders {
+		m := datastarAttrRe.FindStringSubmatch(tc.want)
+		require.NotNil(t, m, tc.want)
+		name := m[1]
+		assert.True(t, strings.Contains(js, `name:"`+name+`"`) || strings.Contains(js, `Q("`+name+`")`) ||
+			strings.Contains(js, `"data-`+name+`"`), "datastar.js has no data-%s", name)
+
+		for _, mod := range strings.Split(m[2], "__")[1:] {
+			key, args, _ := strings.Cut(mod, ".")
+			assert.True(t, regexp.MustCompile(`(has|get)\("`+key+`"\)|__`+key+"`").MatchString(js), "data-%s modifier %s", name, key)
+			for _, arg := range strings.Split(args, ".") {
+				if arg == "" || strings.HasSuffix(arg, "ms") {
+					continue
+				}
+				assert.True(t, regexp.MustCompile(`"`+arg+`"|[{,]`+arg+`:`).MatchString(js), "data-%s modifier %s.%s", name, key, arg)
+			}
+		}
+	}
+}
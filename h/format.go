@@ -0,0 +1,310 @@
+package h
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Pretty renders n as indented HTML, one element per line, for reading
+// patches while debugging. It changes whitespace between elements, which
+// can shift inline layout; use it in development only. Text in pre,
+// textarea, script and style is kept as is.
+func Pretty(w io.Writer, n H) error {
+	nodes, err := parseFormat(n)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	for _, fn := range nodes {
+		writePretty(&b, fn, 0)
+	}
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// Minify renders n with the whitespace the browser would ignore removed,
+// comments dropped and boolean attributes written without a value.
+// Whitespace-only text is kept between inline elements, where it shows,
+// and text in pre, textarea, script and style is kept as is.
+func Minify(w io.Writer, n H) error {
+	nodes, err := parseFormat(n)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	writeMinified(&b, nodes, false)
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+type fmtKind int
+
+const (
+	fmtText fmtKind = iota
+	fmtElement
+	fmtOther // comments, doctypes
+)
+
+// fmtNode is a node of rendered HTML. Names and text keep their original
+// case and escaping; attribute values are escaped again as h writes them.
+type fmtNode struct {
+	kind     fmtKind
+	name     string // element name, or the text of text and other nodes
+	attrs    []fmtAttr
+	children []*fmtNode
+	void     bool
+	selfEnd  bool   // written as <name/>, as in SVG
+	raw      string // content of script, style, textarea and title
+	hasRaw   bool
+}
+
+type fmtAttr struct {
+	name  string
+	value string
+	quote byte // 0 for attributes without a value
+}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+var rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "br": true, "button": true,
+	"cite": true, "code": true, "data": true, "dfn": true, "em": true, "i": true, "img": true,
+	"input": true, "kbd": true, "label": true, "mark": true, "q": true, "s": true, "samp": true,
+	"select": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true,
+	"svg": true, "textarea": true, "time": true, "u": true, "var": true,
+}
+
+var booleanAttrs = map[string]bool{
+	"allowfullscreen": true, "async": true, "autofocus": true, "autoplay": true, "checked": true,
+	"controls": true, "default": true, "defer": true, "disabled": true, "formnovalidate": true,
+	"hidden": true, "inert": true, "ismap": true, "itemscope": true, "loop": true, "multiple": true,
+	"muted": true, "nomodule": true, "novalidate": true, "open": true, "playsinline": true,
+	"readonly": true, "required": true, "reversed": true, "selected": true,
+}
+
+func parseFormat(n H) ([]*fmtNode, error) {
+	var b strings.Builder
+	if err := n.Render(&b); err != nil {
+		return nil, err
+	}
+	return parseFragment(b.String()), nil
+}
+
+// parseFragment reads s into a tree with the html tokenizer. Unlike
+// html.Parse it inserts no elements and moves none, recovering only from
+// stray end tags and unclosed elements: optional end tags such as </li>
+// must be present.
+func parseFragment(s string) []*fmtNode {
+	root := &fmtNode{kind: fmtElement}
+	stack := []*fmtNode{root}
+	add := func(n *fmtNode) {
+		top := stack[len(stack)-1]
+		top.children = append(top.children, n)
+	}
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return root.children
+		case html.TextToken:
+			if top := stack[len(stack)-1]; top.hasRaw {
+				top.raw = string(z.Raw())
+				continue
+			}
+			add(&fmtNode{kind: fmtText, name: string(z.Raw())})
+		case html.CommentToken, html.DoctypeToken:
+			add(&fmtNode{kind: fmtOther, name: string(z.Raw())})
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for j := len(stack) - 1; j > 0; j-- {
+				if strings.EqualFold(stack[j].name, string(name)) {
+					stack = stack[:j]
+					break
+				}
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			el := readTag(z)
+			add(el)
+			lower := strings.ToLower(el.name)
+			switch {
+			case tt == html.SelfClosingTagToken:
+				el.void, el.selfEnd = true, true
+			case voidElements[lower]:
+				el.void = true
+			default:
+				el.hasRaw = rawTextElements[lower]
+				stack = append(stack, el)
+			}
+		}
+	}
+}
+
+// readTag returns the start tag at the current token of z. The tokenizer
+// lowercases names and unescapes values; names are taken from the raw
+// token to keep their case, as in SVG viewBox, and to tell an attribute
+// without a value from an empty one.
+func readTag(z *html.Tokenizer) *fmtNode {
+	raw := z.Raw()
+	orig := string(raw) // TagName and TagAttr lowercase raw in place
+	// offset returns the original text of s, a slice of raw.
+	offset := func(s []byte) (string, int) {
+		i := cap(raw) - cap(s)
+		return orig[i : i+len(s)], i + len(s)
+	}
+	name, more := z.TagName()
+	el := &fmtNode{kind: fmtElement}
+	el.name, _ = offset(name)
+	for more {
+		var key, val []byte
+		key, val, more = z.TagAttr()
+		a := fmtAttr{value: html.EscapeString(string(val))}
+		var end int
+		a.name, end = offset(key)
+		if strings.HasPrefix(strings.TrimLeft(orig[end:], " \t\n\r\f"), "=") {
+			a.quote = '"'
+		}
+		el.attrs = append(el.attrs, a)
+	}
+	return el
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func writeTag(b *bytes.Buffer, n *fmtNode, minify bool) {
+	b.WriteString("<" + n.name)
+	for _, a := range n.attrs {
+		b.WriteString(" " + a.name)
+		if a.quote == 0 {
+			continue
+		}
+		if minify && (a.value == "" || booleanAttrs[strings.ToLower(a.name)] && strings.EqualFold(a.value, a.name)) {
+			continue
+		}
+		b.WriteByte('=')
+		b.WriteByte(a.quote)
+		b.WriteString(a.value)
+		b.WriteByte(a.quote)
+	}
+	if n.selfEnd {
+		b.WriteByte('/')
+	}
+	b.WriteByte('>')
+}
+
+func preformatted(name string) bool {
+	name = strings.ToLower(name)
+	return name == "pre" || rawTextElements[name]
+}
+
+func writeMinified(b *bytes.Buffer, nodes []*fmtNode, pre bool) {
+	for i, n := range nodes {
+		switch n.kind {
+		case fmtOther:
+			if !strings.HasPrefix(n.name, "<!--") {
+				b.WriteString(n.name)
+			}
+		case fmtText:
+			if pre {
+				b.WriteString(n.name)
+				continue
+			}
+			text := collapseSpace(n.name)
+			if text == " " && !(inlineSibling(nodes, i, -1) && inlineSibling(nodes, i, 1)) {
+				continue
+			}
+			b.WriteString(text)
+		case fmtElement:
+			writeTag(b, n, true)
+			if n.void {
+				continue
+			}
+			if n.hasRaw {
+				b.WriteString(n.raw)
+			} else {
+				writeMinified(b, n.children, pre || preformatted(n.name))
+			}
+			b.WriteString("</" + n.name + ">")
+		}
+	}
+}
+
+// inlineSibling reports whether the sibling of nodes[i] in direction dir is
+// text or an inline element.
+func inlineSibling(nodes []*fmtNode, i, dir int) bool {
+	for j := i + dir; j >= 0 && j < len(nodes); j += dir {
+		switch n := nodes[j]; n.kind {
+		case fmtText:
+			return true
+		case fmtElement:
+			return inlineElements[strings.ToLower(n.name)]
+		}
+	}
+	return false
+}
+
+func collapseSpace(s string) string {
+	if !strings.ContainsAny(s, "\t\n\r\f") && !strings.Contains(s, "  ") {
+		return s
+	}
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(s); i++ {
+		if isSpace(s[i]) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(s[i])
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func writePretty(b *bytes.Buffer, n *fmtNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch n.kind {
+	case fmtOther:
+		b.WriteString(indent + n.name + "\n")
+	case fmtText:
+		if text := strings.TrimSpace(collapseSpace(n.name)); text != "" {
+			b.WriteString(indent + text + "\n")
+		}
+	case fmtElement:
+		b.WriteString(indent)
+		writeTag(b, n, false)
+		switch {
+		case n.void:
+			b.WriteByte('\n')
+			return
+		case n.hasRaw:
+			b.WriteString(n.raw)
+		case preformatted(n.name):
+			writeMinified(b, n.children, true)
+		case len(n.children) == 1 && n.children[0].kind == fmtText:
+			b.WriteString(strings.TrimSpace(collapseSpace(n.children[0].name)))
+		case len(n.children) > 0:
+			b.WriteByte('\n')
+			for _, c := range n.children {
+				writePretty(b, c, depth+1)
+			}
+			b.WriteString(indent)
+		}
+		b.WriteString("</" + n.name + ">\n")
+	}
+}
@@ -0,0 +1,110 @@
+package h
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func formatted(t *testing.T, f func(io.Writer, H) error, n H) string {
+	t.Helper()
+	var b strings.Builder
+	require.NoError(t, f(&b, n))
+	return b.String()
+}
+
+var formatSample = Div(ID("x"),
+	Raw("<!-- note -->\n  "),
+	H1(Text("Hello   world")),
+	P(Text("one "), B(Text("two")), Raw(" "), I(Text("three")), Text(" 1 < 2")),
+	Ul(Li(Text("a")), Raw("\n\n  "), Li(Text("b"))),
+	Pre(Text("  keep\n   this ")),
+	Input(Disabled(), Attr("checked", "checked"), Value(""), Data("on:input", "$a = 'b'")),
+	Script(Raw("if (a < b) {\n  x()\n}")),
+	Raw(`<svg viewBox="0 0 1 1"><path d="M0 0"/></svg>`),
+)
+
+func TestMinify(t *testing.T) {
+	assert.Equal(t, `<div id="x"><h1>Hello world</h1><p>one <b>two</b> <i>three</i> 1 &lt; 2</p>`+
+		`<ul><li>a</li><li>b</li></ul><pre>  keep
+   this </pre><input disabled checked value data-on:input="$a = &#39;b&#39;"><script>if (a < b) {
+  x()
+}</script><svg viewBox="0 0 1 1"><path d="M0 0"/></svg></div>`, formatted(t, Minify, formatSample))
+}
+
+func TestPretty(t *testing.T) {
+	assert.Equal(t, `<div id="x">
+  <!-- note -->
+  <h1>Hello world</h1>
+  <p>
+    one
+    <b>two</b>
+    <i>three</i>
+    1 &lt; 2
+  </p>
+  <ul>
+    <li>a</li>
+    <li>b</li>
+  </ul>
+  <pre>  keep
+   this </pre>
+  <input disabled checked="checked" value="" data-on:input="$a = &#39;b&#39;">
+  <script>if (a < b) {
+  x()
+}</script>
+  <svg viewBox="0 0 1 1">
+    <path d="M0 0"/>
+  </svg>
+</div>
+`, formatted(t, Pretty, formatSample))
+}
+
+func TestFormat_Document(t *testing.T) {
+	doc := HTML5(HTML5Props{Title: "T", Body: []H{Main(Text("x"))}})
+	assert.Equal(t, "<!doctype html>\n<html>\n  <head>\n    <meta charset=\"utf-8\">\n"+
+		"    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n    <title>T</title>\n  </head>\n"+
+		"  <body>\n    <main>x</main>\n  </body>\n</html>\n", formatted(t, Pretty, doc))
+	assert.Equal(t, render(t, doc), formatted(t, Minify, doc), "h renders no extra whitespace")
+}
+
+func TestFormat_Malformed(t *testing.T) {
+	n := Raw(`a < b <div class=x><span>open</div></p><p>`)
+	assert.Equal(t, `a < b <div class="x"><span>open</span></div><p></p>`, formatted(t, Minify, n))
+
+	n = Raw(`<DIV Title='say "hi"' data-x = y>ok</div>`)
+	assert.Equal(t, `<DIV Title="say &#34;hi&#34;" data-x="y">ok</DIV>`, formatted(t, Minify, n))
+}
+
+// benchmarkPage is a view of the size of a typical page: a table of 200
+// rows with a few attributes each.
+func benchmarkPage() H {
+	rows := make([]H, 200)
+	for i := range rows {
+		rows[i] = Tr(ID("row"), Class("row"),
+			Td(Textf("%d", i)), Td(Text("Some text in a cell")),
+			Td(Button(Disabled(), Data("on:click", "@get('/_action/x')"), Text("Go"))))
+	}
+	return Div(H1(Text("Table")), Table(TBody(rows...)))
+}
+
+func BenchmarkRender(b *testing.B) {
+	n := benchmarkPage()
+	for _, bc := range []struct {
+		name string
+		f    func(io.Writer, H) error
+	}{
+		{"AsIs", func(w io.Writer, n H) error { return n.Render(w) }},
+		{"Pretty", Pretty},
+		{"Minify", Minify},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_ = bc.f(io.Discard, n)
+			}
+		})
+	}
+}
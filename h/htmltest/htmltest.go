@@ -0,0 +1,225 @@
+// Package htmltest queries rendered HTML in tests, so view tests can assert
+// structure instead of matching substrings.
+//
+// Example:
+//
+//	doc := htmltest.Render(t, view())
+//	assert.Equal(t, "3", doc.Find("#count").Text())
+//	assert.Equal(t, 2, doc.Find("ul > li.done").Len())
+//	inc := doc.Find("button").Action("click") // id of the action the button calls
+package htmltest
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/ryanhamamura/via/h"
+	"github.com/ryanhamamura/via/internal/htmlparse"
+	"golang.org/x/net/html"
+)
+
+// Selection is a list of nodes of a parsed document, in document order.
+type Selection struct {
+	nodes []*html.Node
+}
+
+// Render renders n and parses the result, failing the test on error.
+func Render(t testing.TB, n h.H) *Selection {
+	t.Helper()
+	var b bytes.Buffer
+	if err := n.Render(&b); err != nil {
+		t.Fatalf("htmltest: render: %v", err)
+	}
+	s, err := Parse(&b)
+	if err != nil {
+		t.Fatalf("htmltest: parse: %v", err)
+	}
+	return s
+}
+
+// Parse reads HTML, e.g. a page response body. A full document, starting
+// with a doctype or <html>, is parsed as such; anything else as the content
+// of a body element. The returned selection holds the top level nodes.
+func Parse(r io.Reader) (*Selection, error) {
+	nodes, err := htmlparse.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Selection{nodes: nodes}, nil
+}
+
+// FromNodes returns a selection of nodes parsed with golang.org/x/net/html,
+// e.g. of a document kept up to date by a test harness.
+func FromNodes(nodes ...*html.Node) *Selection {
+	return &Selection{nodes: nodes}
+}
+
+// Find returns the elements in s, and their descendants, that match the CSS
+// selector. It panics if the selector is invalid.
+//
+// Datastar attributes need their colon escaped: [data-on\:click].
+func (s *Selection) Find(selector string) *Selection {
+	sel := cascadia.MustCompile(selector)
+	var found []*html.Node
+	seen := make(map[*html.Node]bool)
+	for _, n := range s.nodes {
+		var match []*html.Node
+		if n.Type == html.ElementNode && sel.Match(n) {
+			match = append(match, n)
+		}
+		match = append(match, sel.MatchAll(n)...)
+		for _, m := range match {
+			if !seen[m] {
+				seen[m] = true
+				found = append(found, m)
+			}
+		}
+	}
+	return &Selection{nodes: found}
+}
+
+// Len returns the number of nodes in s.
+func (s *Selection) Len() int {
+	return len(s.nodes)
+}
+
+// Exists reports whether s holds any node.
+func (s *Selection) Exists() bool {
+	return len(s.nodes) > 0
+}
+
+// At returns the i-th node of s, or an empty selection.
+func (s *Selection) At(i int) *Selection {
+	if i < 0 || i >= len(s.nodes) {
+		return &Selection{}
+	}
+	return &Selection{nodes: s.nodes[i : i+1]}
+}
+
+// First returns the first node of s.
+func (s *Selection) First() *Selection {
+	return s.At(0)
+}
+
+// Each calls fn with each node of s.
+func (s *Selection) Each(fn func(i int, n *Selection)) {
+	for i := range s.nodes {
+		fn(i, s.At(i))
+	}
+}
+
+// Nodes returns the parsed nodes of s.
+func (s *Selection) Nodes() []*html.Node {
+	return s.nodes
+}
+
+// Attr returns the value of attribute name of the first node of s.
+func (s *Selection) Attr(name string) (string, bool) {
+	if len(s.nodes) == 0 {
+		return "", false
+	}
+	for _, a := range s.nodes[0].Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// AttrOr returns the value of attribute name of the first node of s, or
+// def if it has none.
+func (s *Selection) AttrOr(name, def string) string {
+	if v, ok := s.Attr(name); ok {
+		return v
+	}
+	return def
+}
+
+// HasClass reports whether the first node of s has class.
+func (s *Selection) HasClass(class string) bool {
+	v, _ := s.Attr("class")
+	for _, c := range strings.Fields(v) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Text returns the text of the nodes of s and their descendants, with
+// whitespace runs collapsed and trimmed.
+func (s *Selection) Text() string {
+	var b strings.Builder
+	for _, n := range s.nodes {
+		text(&b, n)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func text(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(n.Data)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text(b, c)
+	}
+}
+
+// HTML returns the nodes of s rendered as HTML.
+func (s *Selection) HTML() string {
+	var b strings.Builder
+	for _, n := range s.nodes {
+		_ = html.Render(&b, n)
+	}
+	return b.String()
+}
+
+// Data returns the value of the Datastar attribute data-<name> of the first
+// node of s, ignoring modifiers: Data("on:click") also finds
+// data-on:click__debounce.200ms.
+func (s *Selection) Data(name string) (string, bool) {
+	if len(s.nodes) == 0 {
+		return "", false
+	}
+	key := "data-" + name
+	for _, a := range s.nodes[0].Attr {
+		if a.Key == key || strings.HasPrefix(a.Key, key+"__") {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+var actionRe = regexp.MustCompile(`@(?:get|post)\('/_action/([^']+)'\)`)
+
+// Action returns the id of the Via action the first node of s calls on
+// event, or "" if it calls none.
+func (s *Selection) Action(event string) string {
+	expr, _ := s.Data("on:" + event)
+	if m := actionRe.FindStringSubmatch(expr); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Bind returns the signal that the first node of s binds with data-bind, or
+// "" if it binds none.
+func (s *Selection) Bind() string {
+	if v, ok := s.Data("bind"); ok {
+		return v
+	}
+	if len(s.nodes) == 0 {
+		return ""
+	}
+	for _, a := range s.nodes[0].Attr {
+		if sig, ok := strings.CutPrefix(a.Key, "data-bind:"); ok {
+			sig, _, _ = strings.Cut(sig, "__")
+			return sig
+		}
+	}
+	return ""
+}
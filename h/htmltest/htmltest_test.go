@@ -0,0 +1,70 @@
+package htmltest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func view() h.H {
+	return h.Div(h.ID("todos"), h.Class("list"),
+		h.H1(h.Text("  Todo \n list ")),
+		h.Ul(
+			h.Li(h.Class("item done"), h.Text("milk")),
+			h.Li(h.Class("item"), h.Text("eggs")),
+		),
+		h.Input(h.Type("text"), h.Data("bind", "abc123")),
+		h.Input(h.Data("bind:query__case.kebab", "")),
+		h.Button(h.Data("on:click__debounce.200ms", "$abc123='x',@get('/_action/def456')"), h.Text("Add")),
+	)
+}
+
+func TestFind(t *testing.T) {
+	doc := Render(t, view())
+
+	assert.Equal(t, "Todo list", doc.Find("h1").Text())
+	assert.Equal(t, 2, doc.Find("#todos li.item").Len())
+	assert.Equal(t, "milk", doc.Find("li.done").Text())
+	assert.Equal(t, "eggs", doc.Find("li").At(1).Text())
+	assert.False(t, doc.Find("li").At(2).Exists())
+	assert.True(t, doc.Find("div").HasClass("list"))
+	assert.Equal(t, 1, doc.Find("div").Len(), "the root is matched too")
+	assert.Equal(t, 2, doc.Find("ul").Find("li").Len())
+	assert.Equal(t, `<li class="item">eggs</li>`, doc.Find("li:not(.done)").HTML())
+
+	var texts []string
+	doc.Find("li").Each(func(_ int, li *Selection) { texts = append(texts, li.Text()) })
+	assert.Equal(t, []string{"milk", "eggs"}, texts)
+
+	typ, ok := doc.Find("input").Attr("type")
+	assert.True(t, ok)
+	assert.Equal(t, "text", typ)
+	assert.Equal(t, "none", doc.Find("h1").AttrOr("id", "none"))
+
+	assert.Panics(t, func() { doc.Find("li[") })
+}
+
+func TestDatastar(t *testing.T) {
+	doc := Render(t, view())
+	btn := doc.Find(`[data-on\:click__debounce\.200ms]`)
+	require.Equal(t, 1, btn.Len())
+	assert.Equal(t, "def456", btn.Action("click"))
+	assert.Equal(t, "", btn.Action("input"))
+	expr, ok := btn.Data("on:click")
+	assert.True(t, ok)
+	assert.Contains(t, expr, "$abc123='x'")
+
+	assert.Equal(t, "abc123", doc.Find("input").First().Bind())
+	assert.Equal(t, "query", doc.Find("input").At(1).Bind())
+	assert.Equal(t, "", doc.Find("button").Bind())
+}
+
+func TestParse_Document(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<!doctype html><html><head><title>T</title></head><body><p>x</p></body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, "T", doc.Find("head title").Text())
+	assert.Equal(t, "x", doc.Find("body > p").Text())
+}
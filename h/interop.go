@@ -0,0 +1,86 @@
+package h
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+)
+
+// nodeFunc is a node rendered by a function.
+type nodeFunc func(w io.Writer) error
+
+func (f nodeFunc) Render(w io.Writer) error {
+	return f(w)
+}
+
+// HTMLTemplate renders the template name of t with data, e.g. a partial
+// shipped as an html/template file. The template streams into the page and
+// keeps its own escaping; errors surface from Render. (Template is the
+// <template> element.)
+//
+// Example:
+//
+//	partials := template.Must(template.ParseFS(files, "partials/*.html"))
+//	h.Div(h.HTMLTemplate(partials, "card", product))
+func HTMLTemplate(t *template.Template, name string, data any) H {
+	return nodeFunc(func(w io.Writer) error {
+		return t.ExecuteTemplate(w, name, data)
+	})
+}
+
+// ContextRenderer is a component rendered with a context, such as a templ
+// component.
+type ContextRenderer interface {
+	Render(ctx context.Context, w io.Writer) error
+}
+
+// Component renders c with ctx, so templ components and others of its
+// shape can be placed in views. A nil ctx is context.Background().
+//
+// Example:
+//
+//	h.Main(h.Component(r.Context(), components.Header(user)))
+func Component(ctx context.Context, c ContextRenderer) H {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return nodeFunc(func(w io.Writer) error {
+		return c.Render(ctx, w)
+	})
+}
+
+// componentNode is a node used as a ContextRenderer.
+type componentNode struct {
+	n H
+}
+
+func (c componentNode) Render(_ context.Context, w io.Writer) error {
+	return c.n.Render(w)
+}
+
+// AsComponent returns n as a ContextRenderer, which templ accepts as a
+// templ.Component.
+//
+// Example:
+//
+//	@h.AsComponent(counter.Text())
+func AsComponent(n H) ContextRenderer {
+	return componentNode{n}
+}
+
+// ToHTML renders n for use in html/template, which inserts template.HTML
+// as is. Register it as a template function to render nodes in place:
+//
+//	t := template.New("page").Funcs(template.FuncMap{"h": h.ToHTML})
+//	// {{ h .Chart }}
+//
+// n must be trusted: h escapes text and attribute values, but h.Raw and
+// h.Rawf do not.
+func ToHTML(n H) (template.HTML, error) {
+	var b bytes.Buffer
+	if err := n.Render(&b); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
@@ -0,0 +1,65 @@
+package h
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLTemplate(t *testing.T) {
+	tpl := template.Must(template.New("").Parse(`{{define "card"}}<p title="{{.}}">{{.}}</p>{{end}}`))
+	assert.Equal(t, `<div><p title="&lt;b&gt; &amp; &#34;q&#34;">&lt;b&gt; &amp; &#34;q&#34;</p></div>`,
+		render(t, Div(HTMLTemplate(tpl, "card", `<b> & "q"`))))
+
+	var b strings.Builder
+	err := Div(HTMLTemplate(tpl, "missing", nil)).Render(&b)
+	assert.ErrorContains(t, err, `"missing" is undefined`)
+}
+
+type ctxKey struct{}
+
+type fakeComponent struct{ text string }
+
+func (c fakeComponent) Render(ctx context.Context, w io.Writer) error {
+	if c.text == "" {
+		return errors.New("empty component")
+	}
+	_, err := fmt.Fprintf(w, "<span>%s %v</span>", c.text, ctx.Value(ctxKey{}))
+	return err
+}
+
+func TestComponent(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "ctx")
+	assert.Equal(t, `<div><span>hi ctx</span></div>`, render(t, Div(Component(ctx, fakeComponent{"hi"}))))
+	assert.Equal(t, `<span>hi <nil></span>`, render(t, Component(nil, fakeComponent{"hi"})))
+
+	var b strings.Builder
+	assert.EqualError(t, Div(Component(ctx, fakeComponent{})).Render(&b), "empty component")
+}
+
+func TestAsComponent(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, AsComponent(P(Text("<x>"))).Render(context.Background(), &b))
+	assert.Equal(t, `<p>&lt;x&gt;</p>`, b.String())
+}
+
+func TestToHTML(t *testing.T) {
+	tpl := template.Must(template.New("page").Funcs(template.FuncMap{"h": ToHTML}).
+		Parse(`<main>{{h .Chart}}{{.Title}}</main>`))
+	var b strings.Builder
+	require.NoError(t, tpl.Execute(&b, map[string]any{"Chart": Div(Class("c"), Text("<1>")), "Title": "<t>"}))
+	assert.Equal(t, `<main><div class="c">&lt;1&gt;</div>&lt;t&gt;</main>`, b.String())
+
+	failing := Component(context.Background(), fakeComponent{})
+	_, err := ToHTML(failing)
+	assert.Error(t, err)
+	err = tpl.Execute(&b, map[string]any{"Chart": failing})
+	assert.ErrorContains(t, err, "empty component")
+}
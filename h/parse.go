@@ -0,0 +1,40 @@
+package h
+
+import (
+	"io"
+
+	"github.com/ryanhamamura/via/internal/htmlparse"
+	"golang.org/x/net/html"
+)
+
+// Parse reads HTML into a node, e.g. to place markup from a file or a CMS
+// in a view. A full document, starting with a doctype or <html>, keeps its
+// structure; anything else is read as the content of a body element, as
+// the browser would, so tags that can not appear there are dropped.
+//
+// The HTML is normalized, not kept byte for byte: end tags are added where
+// they were implied, entities are re-escaped and attribute values quoted.
+//
+// Example:
+//
+//	about, err := h.Parse(strings.NewReader(aboutHTML))
+func Parse(r io.Reader) (H, error) {
+	nodes, err := htmlparse.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return parsed(nodes), nil
+}
+
+// parsed is a list of nodes read by Parse.
+type parsed []*html.Node
+
+// Render writes the parsed nodes as HTML.
+func (p parsed) Render(w io.Writer) error {
+	for _, n := range p {
+		if err := html.Render(w, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package h
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	n, err := Parse(strings.NewReader(`<p class=intro>Hi &amp; <b>welcome<p>next <svg viewBox="0 0 1 1"><path d="M0"/></svg>`))
+	require.NoError(t, err)
+	assert.Equal(t, `<div><p class="intro">Hi &amp; <b>welcome</b></p><p><b>next <svg viewBox="0 0 1 1"><path d="M0"></path></svg></b></p></div>`,
+		render(t, Div(n)))
+}
+
+func TestParse_Document(t *testing.T) {
+	n, err := Parse(strings.NewReader("\n<!DOCTYPE html><title>T</title><main>x</main>"))
+	require.NoError(t, err)
+	assert.Equal(t, `<!DOCTYPE html><html><head><title>T</title></head><body><main>x</main></body></html>`, render(t, n))
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	want := render(t, Div(ID("a"), Data("on:click", "@get('/x')"), Input(Disabled(), Value("<v>"))))
+	n, err := Parse(strings.NewReader(want))
+	require.NoError(t, err)
+	assert.Equal(t, `<div id="a" data-on:click="@get(&#39;/x&#39;)"><input disabled="" value="&lt;v&gt;"/></div>`, render(t, n))
+}
@@ -0,0 +1,268 @@
+package svg
+
+import "github.com/ryanhamamura/via/h"
+
+func AttributeName(v string) h.H {
+	return h.Attr("attributeName", v)
+}
+
+func Begin(v string) h.H {
+	return h.Attr("begin", v)
+}
+
+// ClipPathAttr clips an element, e.g. ClipPathAttr("url(#clip)").
+func ClipPathAttr(v string) h.H {
+	return h.Attr("clip-path", v)
+}
+
+func ClipPathUnits(v string) h.H {
+	return h.Attr("clipPathUnits", v)
+}
+
+func CX(v string) h.H {
+	return h.Attr("cx", v)
+}
+
+func CY(v string) h.H {
+	return h.Attr("cy", v)
+}
+
+// D sets the path data of a Path; see PathData.
+func D(v string) h.H {
+	return h.Attr("d", v)
+}
+
+func DominantBaseline(v string) h.H {
+	return h.Attr("dominant-baseline", v)
+}
+
+func Dur(v string) h.H {
+	return h.Attr("dur", v)
+}
+
+func DX(v string) h.H {
+	return h.Attr("dx", v)
+}
+
+func DY(v string) h.H {
+	return h.Attr("dy", v)
+}
+
+func Fill(v string) h.H {
+	return h.Attr("fill", v)
+}
+
+func FillOpacity(v string) h.H {
+	return h.Attr("fill-opacity", v)
+}
+
+func FillRule(v string) h.H {
+	return h.Attr("fill-rule", v)
+}
+
+func FilterAttr(v string) h.H {
+	return h.Attr("filter", v)
+}
+
+func FontFamily(v string) h.H {
+	return h.Attr("font-family", v)
+}
+
+func FontSize(v string) h.H {
+	return h.Attr("font-size", v)
+}
+
+func FontWeight(v string) h.H {
+	return h.Attr("font-weight", v)
+}
+
+func From(v string) h.H {
+	return h.Attr("from", v)
+}
+
+func FX(v string) h.H {
+	return h.Attr("fx", v)
+}
+
+func FY(v string) h.H {
+	return h.Attr("fy", v)
+}
+
+func GradientTransform(v string) h.H {
+	return h.Attr("gradientTransform", v)
+}
+
+func GradientUnits(v string) h.H {
+	return h.Attr("gradientUnits", v)
+}
+
+func Height(v string) h.H {
+	return h.Attr("height", v)
+}
+
+// Href references another element, e.g. Use(Href("#icon")), or a URL.
+func Href(v string) h.H {
+	return h.Attr("href", v)
+}
+
+func MarkerEnd(v string) h.H {
+	return h.Attr("marker-end", v)
+}
+
+func MarkerHeight(v string) h.H {
+	return h.Attr("markerHeight", v)
+}
+
+func MarkerMid(v string) h.H {
+	return h.Attr("marker-mid", v)
+}
+
+func MarkerStart(v string) h.H {
+	return h.Attr("marker-start", v)
+}
+
+func MarkerWidth(v string) h.H {
+	return h.Attr("markerWidth", v)
+}
+
+func MaskAttr(v string) h.H {
+	return h.Attr("mask", v)
+}
+
+func Offset(v string) h.H {
+	return h.Attr("offset", v)
+}
+
+func Opacity(v string) h.H {
+	return h.Attr("opacity", v)
+}
+
+func Orient(v string) h.H {
+	return h.Attr("orient", v)
+}
+
+func PathLength(v string) h.H {
+	return h.Attr("pathLength", v)
+}
+
+func PatternUnits(v string) h.H {
+	return h.Attr("patternUnits", v)
+}
+
+func PreserveAspectRatio(v string) h.H {
+	return h.Attr("preserveAspectRatio", v)
+}
+
+func R(v string) h.H {
+	return h.Attr("r", v)
+}
+
+func RefX(v string) h.H {
+	return h.Attr("refX", v)
+}
+
+func RefY(v string) h.H {
+	return h.Attr("refY", v)
+}
+
+func RepeatCount(v string) h.H {
+	return h.Attr("repeatCount", v)
+}
+
+func RX(v string) h.H {
+	return h.Attr("rx", v)
+}
+
+func RY(v string) h.H {
+	return h.Attr("ry", v)
+}
+
+func SpreadMethod(v string) h.H {
+	return h.Attr("spreadMethod", v)
+}
+
+func StopColor(v string) h.H {
+	return h.Attr("stop-color", v)
+}
+
+func StopOpacity(v string) h.H {
+	return h.Attr("stop-opacity", v)
+}
+
+func Stroke(v string) h.H {
+	return h.Attr("stroke", v)
+}
+
+func StrokeDasharray(v string) h.H {
+	return h.Attr("stroke-dasharray", v)
+}
+
+func StrokeDashoffset(v string) h.H {
+	return h.Attr("stroke-dashoffset", v)
+}
+
+func StrokeLinecap(v string) h.H {
+	return h.Attr("stroke-linecap", v)
+}
+
+func StrokeLinejoin(v string) h.H {
+	return h.Attr("stroke-linejoin", v)
+}
+
+func StrokeOpacity(v string) h.H {
+	return h.Attr("stroke-opacity", v)
+}
+
+func StrokeWidth(v string) h.H {
+	return h.Attr("stroke-width", v)
+}
+
+func TextAnchor(v string) h.H {
+	return h.Attr("text-anchor", v)
+}
+
+func To(v string) h.H {
+	return h.Attr("to", v)
+}
+
+func Values(v string) h.H {
+	return h.Attr("values", v)
+}
+
+func VectorEffect(v string) h.H {
+	return h.Attr("vector-effect", v)
+}
+
+// ViewBox sets the user space of an SVG, e.g. "0 0 100 20". Shapes scale
+// with the element size.
+func ViewBox(v string) h.H {
+	return h.Attr("viewBox", v)
+}
+
+func Width(v string) h.H {
+	return h.Attr("width", v)
+}
+
+func X(v string) h.H {
+	return h.Attr("x", v)
+}
+
+func X1(v string) h.H {
+	return h.Attr("x1", v)
+}
+
+func X2(v string) h.H {
+	return h.Attr("x2", v)
+}
+
+func Y(v string) h.H {
+	return h.Attr("y", v)
+}
+
+func Y1(v string) h.H {
+	return h.Attr("y1", v)
+}
+
+func Y2(v string) h.H {
+	return h.Attr("y2", v)
+}
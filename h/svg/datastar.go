@@ -0,0 +1,32 @@
+package svg
+
+import "github.com/ryanhamamura/via/h"
+
+// DataAttr binds attribute name to a Datastar expression, e.g. a signal.
+//
+// Datastar sets the attribute named in data-attr:name as written, but HTML
+// lowercases attribute names, which breaks camelCase SVG attributes such as
+// viewBox. DataAttr therefore renders those in the object form
+// data-attr="{'viewBox': expr}". An element takes one object form; bind
+// several camelCase attributes with DataAttrs.
+//
+// Example:
+//
+//	svg.Circle(svg.R("4"), svg.DataAttr("cx", "$x"), svg.DataAttr("cy", "$y"))
+func DataAttr(name, expr string) h.H {
+	return h.DataAttr(name, h.Expr(expr))
+}
+
+// DataAttrs binds several attributes to Datastar expressions in one
+// data-attr attribute, keeping the case of their names.
+//
+// Example:
+//
+//	svg.SVG(svg.DataAttrs(map[string]string{"viewBox": "$box", "preserveAspectRatio": "'none'"}))
+func DataAttrs(attrs map[string]string) h.H {
+	exprs := make(map[string]h.Expr, len(attrs))
+	for name, expr := range attrs {
+		exprs[name] = h.Expr(expr)
+	}
+	return h.DataAttrs(exprs)
+}
@@ -0,0 +1,124 @@
+package svg
+
+import "github.com/ryanhamamura/via/h"
+
+func A(children ...h.H) h.H {
+	return el("a", children...)
+}
+
+func Animate(children ...h.H) h.H {
+	return el("animate", children...)
+}
+
+func AnimateTransform(children ...h.H) h.H {
+	return el("animateTransform", children...)
+}
+
+func Circle(children ...h.H) h.H {
+	return el("circle", children...)
+}
+
+func ClipPath(children ...h.H) h.H {
+	return el("clipPath", children...)
+}
+
+func Defs(children ...h.H) h.H {
+	return el("defs", children...)
+}
+
+func Desc(children ...h.H) h.H {
+	return el("desc", children...)
+}
+
+func Ellipse(children ...h.H) h.H {
+	return el("ellipse", children...)
+}
+
+func Filter(children ...h.H) h.H {
+	return el("filter", children...)
+}
+
+func ForeignObject(children ...h.H) h.H {
+	return el("foreignObject", children...)
+}
+
+// G groups shapes, e.g. to transform them together.
+func G(children ...h.H) h.H {
+	return el("g", children...)
+}
+
+func Image(children ...h.H) h.H {
+	return el("image", children...)
+}
+
+func Line(children ...h.H) h.H {
+	return el("line", children...)
+}
+
+func LinearGradient(children ...h.H) h.H {
+	return el("linearGradient", children...)
+}
+
+func Marker(children ...h.H) h.H {
+	return el("marker", children...)
+}
+
+func Mask(children ...h.H) h.H {
+	return el("mask", children...)
+}
+
+func Path(children ...h.H) h.H {
+	return el("path", children...)
+}
+
+func Pattern(children ...h.H) h.H {
+	return el("pattern", children...)
+}
+
+func Polygon(children ...h.H) h.H {
+	return el("polygon", children...)
+}
+
+func Polyline(children ...h.H) h.H {
+	return el("polyline", children...)
+}
+
+func RadialGradient(children ...h.H) h.H {
+	return el("radialGradient", children...)
+}
+
+func Rect(children ...h.H) h.H {
+	return el("rect", children...)
+}
+
+// Stop is a color stop of a gradient.
+func Stop(children ...h.H) h.H {
+	return el("stop", children...)
+}
+
+func Symbol(children ...h.H) h.H {
+	return el("symbol", children...)
+}
+
+func Text(children ...h.H) h.H {
+	return el("text", children...)
+}
+
+func TextPath(children ...h.H) h.H {
+	return el("textPath", children...)
+}
+
+// Title is the accessible name of its parent element, shown as a tooltip.
+func Title(children ...h.H) h.H {
+	return el("title", children...)
+}
+
+func TSpan(children ...h.H) h.H {
+	return el("tspan", children...)
+}
+
+// Use renders a copy of the element referenced with Href, e.g. an icon
+// defined once in Defs.
+func Use(children ...h.H) h.H {
+	return el("use", children...)
+}
@@ -0,0 +1,74 @@
+// Package svg provides typed SVG elements and attributes that compose with
+// [h.H] nodes.
+//
+// Example:
+//
+//	svg.SVG(svg.ViewBox("0 0 100 20"), h.Class("sparkline"),
+//		svg.Polyline(svg.Points(pts...), svg.Fill("none"), svg.Stroke("currentColor")),
+//	)
+//
+// SVG element and attribute names are case sensitive (linearGradient,
+// viewBox); the functions here render the correct case. SVG renders the
+// xmlns attribute, so markup also works outside of an HTML document.
+//
+// Browsers only place elements in the SVG namespace when they are parsed
+// inside an <svg> element. Patch whole <svg> elements, e.g. by giving the
+// svg element an ID and syncing it, rather than shapes on their own.
+package svg
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ryanhamamura/via/h"
+	g "maragu.dev/gomponents"
+)
+
+// Namespace is the XML namespace of SVG elements.
+const Namespace = "http://www.w3.org/2000/svg"
+
+// SVG creates an <svg> root element in the SVG namespace.
+func SVG(children ...h.H) h.H {
+	return el("svg", append([]h.H{h.Attr("xmlns", Namespace)}, children...)...)
+}
+
+func el(name string, children ...h.H) h.H {
+	nodes := make([]g.Node, len(children))
+	for i, c := range children {
+		if c != nil {
+			nodes[i] = c.(g.Node)
+		}
+	}
+	return g.El(name, nodes...)
+}
+
+// Point is a coordinate in user space.
+type Point struct {
+	X, Y float64
+}
+
+// Points creates a points attribute for Polyline and Polygon.
+func Points(pts ...Point) h.H {
+	var b strings.Builder
+	for i, p := range pts {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(num(p.X))
+		b.WriteByte(',')
+		b.WriteString(num(p.Y))
+	}
+	return h.Attr("points", b.String())
+}
+
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func nums(fs ...float64) string {
+	s := make([]string, len(fs))
+	for i, f := range fs {
+		s[i] = num(f)
+	}
+	return strings.Join(s, " ")
+}
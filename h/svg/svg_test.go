@@ -0,0 +1,118 @@
+package svg
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, n h.H) string {
+	t.Helper()
+	var b strings.Builder
+	require.NoError(t, n.Render(&b))
+	return b.String()
+}
+
+func TestSVG_RendersNamespacedDocument(t *testing.T) {
+	icon := SVG(ViewBox("0 0 24 24"), Width("24"), h.Class("icon"),
+		Defs(LinearGradient(h.ID("grad"), GradientUnits("userSpaceOnUse"),
+			Stop(Offset("0"), StopColor("#fff")),
+			Stop(Offset("1"), StopColor("#000"), StopOpacity("0.5")),
+		)),
+		G(Transform(Translate(2, 2), Rotate(45, 12, 12), Scale(0.5)),
+			Circle(CX("12"), CY("12"), R("10"), Fill("url(#grad)")),
+			Rect(X("1"), Y("2"), RX("3"), Width("4"), Height("5")),
+		),
+		Text(X("12"), Y("20"), TextAnchor("middle"), h.Text("a < b")),
+	)
+	out := render(t, icon)
+
+	assert.True(t, strings.HasPrefix(out, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24" width="24" class="icon">`), out)
+	assert.Contains(t, out, `<linearGradient id="grad" gradientUnits="userSpaceOnUse">`)
+	assert.Contains(t, out, `<stop offset="1" stop-color="#000" stop-opacity="0.5"></stop>`)
+	assert.Contains(t, out, `<g transform="translate(2 2) rotate(45 12 12) scale(0.5)">`)
+	assert.Contains(t, out, `<text x="12" y="20" text-anchor="middle">a &lt; b</text>`)
+
+	// the markup is valid XML in the SVG namespace, case intact
+	dec := xml.NewDecoder(strings.NewReader(out))
+	var names []xml.Name
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			names = append(names, se.Name)
+		}
+	}
+	require.NotEmpty(t, names)
+	for _, n := range names {
+		assert.Equal(t, Namespace, n.Space, n.Local)
+	}
+	assert.Equal(t, "linearGradient", names[2].Local)
+}
+
+func TestElements(t *testing.T) {
+	for name, el := range map[string]func(...h.H) h.H{
+		"a": A, "animate": Animate, "animateTransform": AnimateTransform, "circle": Circle,
+		"clipPath": ClipPath, "defs": Defs, "desc": Desc, "ellipse": Ellipse, "filter": Filter,
+		"foreignObject": ForeignObject, "g": G, "image": Image, "line": Line,
+		"linearGradient": LinearGradient, "marker": Marker, "mask": Mask, "path": Path,
+		"pattern": Pattern, "polygon": Polygon, "polyline": Polyline, "radialGradient": RadialGradient,
+		"rect": Rect, "stop": Stop, "symbol": Symbol, "text": Text, "textPath": TextPath,
+		"title": Title, "tspan": TSpan, "use": Use,
+	} {
+		assert.Equal(t, "<"+name+` id="x"></`+name+">", render(t, el(h.ID("x"))))
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	for want, attr := range map[string]func(string) h.H{
+		"attributeName": AttributeName, "begin": Begin, "clip-path": ClipPathAttr,
+		"clipPathUnits": ClipPathUnits, "cx": CX, "cy": CY, "d": D, "dominant-baseline": DominantBaseline,
+		"dur": Dur, "dx": DX, "dy": DY, "fill": Fill, "fill-opacity": FillOpacity, "fill-rule": FillRule,
+		"filter": FilterAttr, "font-family": FontFamily, "font-size": FontSize, "font-weight": FontWeight,
+		"from": From, "fx": FX, "fy": FY, "gradientTransform": GradientTransform,
+		"gradientUnits": GradientUnits, "height": Height, "href": Href, "marker-end": MarkerEnd,
+		"markerHeight": MarkerHeight, "marker-mid": MarkerMid, "marker-start": MarkerStart,
+		"markerWidth": MarkerWidth, "mask": MaskAttr, "offset": Offset, "opacity": Opacity,
+		"orient": Orient, "pathLength": PathLength, "patternUnits": PatternUnits,
+		"preserveAspectRatio": PreserveAspectRatio, "r": R, "refX": RefX, "refY": RefY,
+		"repeatCount": RepeatCount, "rx": RX, "ry": RY, "spreadMethod": SpreadMethod,
+		"stop-color": StopColor, "stop-opacity": StopOpacity, "stroke": Stroke,
+		"stroke-dasharray": StrokeDasharray, "stroke-dashoffset": StrokeDashoffset,
+		"stroke-linecap": StrokeLinecap, "stroke-linejoin": StrokeLinejoin,
+		"stroke-opacity": StrokeOpacity, "stroke-width": StrokeWidth, "text-anchor": TextAnchor,
+		"to": To, "values": Values, "vector-effect": VectorEffect, "viewBox": ViewBox,
+		"width": Width, "x": X, "x1": X1, "x2": X2, "y": Y, "y1": Y1, "y2": Y2,
+	} {
+		assert.Equal(t, " "+want+`="v"`, render(t, attr("v")))
+	}
+}
+
+func TestPathDataAndPoints(t *testing.T) {
+	var p PathData
+	p.M(0, 10).L(5, 2.5).H(8).V(1).C(1, 2, 3, 4, 5, 6).Q(1, 2, 3, 4).A(5, 5, 0, true, false, 10, 10).Z()
+	assert.Equal(t, "M0 10 L5 2.5 H8 V1 C1 2 3 4 5 6 Q1 2 3 4 A5 5 0 1 0 10 10 Z", p.String())
+	assert.Equal(t, `<path d="M0 10 L5 2.5 H8 V1 C1 2 3 4 5 6 Q1 2 3 4 A5 5 0 1 0 10 10 Z"></path>`, render(t, Path(p.D())))
+
+	assert.Equal(t, ` points="0,10 1.5,-2"`, render(t, Points(Point{0, 10}, Point{1.5, -2})))
+	assert.Equal(t, ` transform="skewX(10) skewY(-5) matrix(1 0 0 1 2 3)"`,
+		render(t, Transform(SkewX(10), SkewY(-5), Matrix(1, 0, 0, 1, 2, 3))))
+}
+
+func TestDataAttr(t *testing.T) {
+	assert.Equal(t, ` data-attr:cx="$x"`, render(t, DataAttr("cx", "$x")))
+	assert.Equal(t, ` data-attr:stroke-width="$w"`, render(t, DataAttr("stroke-width", "$w")))
+	assert.Equal(t, ` data-attr="{&#39;viewBox&#39;: $box}"`, render(t, DataAttr("viewBox", "$box")))
+	assert.Equal(t, ` data-attr="{&#39;d&#39;: $path, &#39;viewBox&#39;: $box}"`,
+		render(t, DataAttrs(map[string]string{"viewBox": "$box", "d": "$path"})))
+
+	// composes with h elements and attributes
+	out := render(t, h.Div(h.Class("chart"), SVG(h.Data("show", "$visible"), Path(DataAttr("d", "$line")))))
+	assert.Equal(t, `<div class="chart"><svg xmlns="http://www.w3.org/2000/svg" data-show="$visible"><path data-attr:d="$line"></path></svg></div>`, out)
+}
@@ -0,0 +1,109 @@
+package svg
+
+import (
+	"strings"
+
+	"github.com/ryanhamamura/via/h"
+)
+
+// Transform creates a transform attribute applying ops from left to right.
+//
+// Example:
+//
+//	svg.G(svg.Transform(svg.Translate(10, 20), svg.Rotate(45)), ...)
+func Transform(ops ...string) h.H {
+	return h.Attr("transform", strings.Join(ops, " "))
+}
+
+func Translate(x, y float64) string {
+	return "translate(" + nums(x, y) + ")"
+}
+
+// Rotate rotates by deg degrees around the origin, or around the point
+// given as two further arguments.
+func Rotate(deg float64, center ...float64) string {
+	return "rotate(" + nums(append([]float64{deg}, center...)...) + ")"
+}
+
+// Scale scales by s in both directions, or by two arguments per axis.
+func Scale(s ...float64) string {
+	return "scale(" + nums(s...) + ")"
+}
+
+func SkewX(deg float64) string {
+	return "skewX(" + num(deg) + ")"
+}
+
+func SkewY(deg float64) string {
+	return "skewY(" + num(deg) + ")"
+}
+
+func Matrix(a, b, c, d, e, f float64) string {
+	return "matrix(" + nums(a, b, c, d, e, f) + ")"
+}
+
+// PathData builds the d attribute of a Path in absolute coordinates.
+//
+// Example:
+//
+//	var p svg.PathData
+//	p.M(0, 10).L(5, 2).L(10, 6)
+//	svg.Path(p.D(), svg.Stroke("currentColor"))
+type PathData struct {
+	b strings.Builder
+}
+
+func (p *PathData) cmd(c string, args ...float64) *PathData {
+	if p.b.Len() > 0 {
+		p.b.WriteByte(' ')
+	}
+	p.b.WriteString(c)
+	if len(args) > 0 {
+		p.b.WriteString(nums(args...))
+	}
+	return p
+}
+
+// M moves to x, y without drawing.
+func (p *PathData) M(x, y float64) *PathData { return p.cmd("M", x, y) }
+
+// L draws a line to x, y.
+func (p *PathData) L(x, y float64) *PathData { return p.cmd("L", x, y) }
+
+// H draws a horizontal line to x.
+func (p *PathData) H(x float64) *PathData { return p.cmd("H", x) }
+
+// V draws a vertical line to y.
+func (p *PathData) V(y float64) *PathData { return p.cmd("V", y) }
+
+// C draws a cubic Bézier curve to x, y with control points x1, y1 and
+// x2, y2.
+func (p *PathData) C(x1, y1, x2, y2, x, y float64) *PathData {
+	return p.cmd("C", x1, y1, x2, y2, x, y)
+}
+
+// Q draws a quadratic Bézier curve to x, y with control point x1, y1.
+func (p *PathData) Q(x1, y1, x, y float64) *PathData {
+	return p.cmd("Q", x1, y1, x, y)
+}
+
+// A draws an elliptical arc to x, y.
+func (p *PathData) A(rx, ry, rotation float64, largeArc, sweep bool, x, y float64) *PathData {
+	return p.cmd("A", rx, ry, rotation, flag(largeArc), flag(sweep), x, y)
+}
+
+// Z closes the path.
+func (p *PathData) Z() *PathData { return p.cmd("Z") }
+
+// String returns the path data.
+func (p *PathData) String() string { return p.b.String() }
+
+// D returns the path data as a d attribute.
+func (p *PathData) D() h.H { return D(p.String()) }
+
+func flag(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"log"
 	"math/rand"
 
 	"github.com/ryanhamamura/via"
 	"github.com/ryanhamamura/via/h"
+	"github.com/ryanhamamura/via/room"
 )
 
 var (
@@ -83,8 +85,12 @@ func main() {
 				}
 			`)),
 	)
-	rooms := NewRooms[Chat, UserInfo]("Clojure", "Dotnet", "Go", "Java", "JS", "Kotlin", "Python", "Rust")
-	rooms.Start()
+	rooms := room.NewRooms[Chat, UserInfo]()
+	for _, n := range []string{"Clojure", "Dotnet", "Go", "Java", "JS", "Kotlin", "Python", "Rust"} {
+		if _, err := rooms.Create(n); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	v.Page("/", func(c *via.Context) {
 		roomName := c.Signal("Go")
@@ -95,7 +101,7 @@ func main() {
 		currentUser := NewUserInfo(randAnimal())
 		statement := c.Signal("")
 
-		var currentRoom *Room[Chat, UserInfo]
+		var currentRoom *room.Room[Chat, UserInfo]
 
 		switchRoom := func() {
 			newRoom, ok := rooms.Get(string(roomName.String()))
@@ -103,9 +109,9 @@ func main() {
 				return
 			}
 			if currentRoom != nil && currentRoom != newRoom {
-				currentRoom.Leave(&currentUser)
+				currentRoom.Leave(currentUser)
 			}
-			newRoom.Join(&UserAndSync[Chat, UserInfo]{user: &currentUser, sync: c})
+			newRoom.Join(c, currentUser)
 			currentRoom = newRoom
 			roomNameString = newRoom.Name
 		}
@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"html"
 	"log"
-	"sync"
+	"slices"
 	"time"
 
 	"github.com/ryanhamamura/via"
@@ -28,10 +28,7 @@ type CRUDEvent struct {
 	UserID string `json:"user_id"`
 }
 
-var (
-	bookmarks   []Bookmark
-	bookmarksMu sync.RWMutex
-)
+var bookmarks = via.NewStore([]Bookmark{})
 
 func randomHex(n int) string {
 	b := make([]byte, n)
@@ -39,7 +36,7 @@ func randomHex(n int) string {
 	return fmt.Sprintf("%x", b)
 }
 
-func findBookmark(id string) (Bookmark, int) {
+func findBookmark(bookmarks []Bookmark, id string) (Bookmark, int) {
 	for i, bm := range bookmarks {
 		if bm.ID == id {
 			return bm, i
@@ -87,6 +84,7 @@ func main() {
 		titleSignal := c.Signal("")
 		urlSignal := c.Signal("")
 		targetIDSignal := c.Signal("")
+		list := via.Use(c, bookmarks, func(b []Bookmark) []Bookmark { return b })
 
 		via.Subscribe(c, "bookmarks.events", func(evt CRUDEvent) {
 			if evt.UserID == userID {
@@ -111,7 +109,6 @@ func main() {
 				tc.appendChild(d);
 				setTimeout(function(){ d.remove(); }, 3000);
 			})()`, alertClass, safeTitle, evt.Action))
-			c.Sync()
 		})
 
 		save := c.Action(func() {
@@ -124,21 +121,25 @@ func main() {
 			targetID := targetIDSignal.String()
 			action := "created"
 
-			bookmarksMu.Lock()
-			if targetID != "" {
-				if _, idx := findBookmark(targetID); idx >= 0 {
-					bookmarks[idx].Title = title
-					bookmarks[idx].URL = url
-					action = "updated"
+			// views read the current slice without a lock, so changes are
+			// made to a copy that replaces it
+			bookmarks.Update(func(bms *[]Bookmark) {
+				nb := slices.Clone(*bms)
+				if targetID != "" {
+					if _, idx := findBookmark(nb, targetID); idx >= 0 {
+						nb[idx].Title = title
+						nb[idx].URL = url
+						action = "updated"
+					}
+				} else {
+					nb = append(nb, Bookmark{
+						ID:    randomHex(8),
+						Title: title,
+						URL:   url,
+					})
 				}
-			} else {
-				bookmarks = append(bookmarks, Bookmark{
-					ID:    randomHex(8),
-					Title: title,
-					URL:   url,
-				})
-			}
-			bookmarksMu.Unlock()
+				*bms = nb
+			})
 
 			titleSignal.SetValue("")
 			urlSignal.SetValue("")
@@ -154,9 +155,7 @@ func main() {
 
 		edit := c.Action(func() {
 			id := targetIDSignal.String()
-			bookmarksMu.RLock()
-			bm, idx := findBookmark(id)
-			bookmarksMu.RUnlock()
+			bm, idx := findBookmark(list(), id)
 			if idx < 0 {
 				return
 			}
@@ -166,12 +165,14 @@ func main() {
 
 		del := c.Action(func() {
 			id := targetIDSignal.String()
-			bookmarksMu.Lock()
-			bm, idx := findBookmark(id)
-			if idx >= 0 {
-				bookmarks = append(bookmarks[:idx], bookmarks[idx+1:]...)
-			}
-			bookmarksMu.Unlock()
+			var bm Bookmark
+			idx := -1
+			bookmarks.Update(func(bms *[]Bookmark) {
+				bm, idx = findBookmark(*bms, id)
+				if idx >= 0 {
+					*bms = slices.Delete(slices.Clone(*bms), idx, idx+1)
+				}
+			})
 			if idx < 0 {
 				return
 			}
@@ -196,9 +197,8 @@ func main() {
 			isEditing := targetIDSignal.String() != ""
 
 			// Build table rows
-			bookmarksMu.RLock()
 			var rows []h.H
-			for _, bm := range bookmarks {
+			for _, bm := range list() {
 				rows = append(rows, h.Tr(
 					h.Td(h.Text(bm.Title)),
 					h.Td(h.A(h.Href(bm.URL), h.Attr("target", "_blank"), h.Class("link link-primary"), h.Text(bm.URL))),
@@ -214,7 +214,6 @@ func main() {
 					),
 				))
 			}
-			bookmarksMu.RUnlock()
 
 			saveLabel := "Add Bookmark"
 			if isEditing {
@@ -31,14 +31,14 @@ func main() {
 
 	// Login page
 	v.Page("/login", func(c *via.Context) {
-		flash := c.Session().PopString("flash")
+		flashes := c.FlashMessages()
 		usernameInput := c.Signal("")
 
 		login := c.Action(func() {
 			name := usernameInput.String()
 			if name != "" {
 				c.Session().Set("username", name)
-				c.Session().Set("flash", "Welcome, "+name+"!")
+				c.Flash("success", "Welcome, "+name+"!")
 				c.Session().RenewToken()
 				c.Redirect("/dashboard")
 			}
@@ -51,12 +51,8 @@ func main() {
 				return h.Div()
 			}
 
-			var flashMsg h.H
-			if flash != "" {
-				flashMsg = h.P(h.Text(flash), h.Style("color: green"))
-			}
 			return h.Div(
-				flashMsg,
+				flashes(),
 				h.H1(h.Text("Login")),
 				h.Input(h.Type("text"), h.Placeholder("Username"), usernameInput.Bind()),
 				h.Button(h.Text("Login"), login.OnClick()),
@@ -66,8 +62,9 @@ func main() {
 
 	// Dashboard page (protected)
 	v.Page("/dashboard", func(c *via.Context) {
+		flashes := c.FlashMessages()
 		logout := c.Action(func() {
-			c.Session().Set("flash", "Goodbye!")
+			c.Flash("info", "Goodbye!")
 			c.Session().Delete("username")
 			c.Redirect("/login")
 		})
@@ -77,18 +74,13 @@ func main() {
 
 			// Not logged in? Redirect to login
 			if username == "" {
-				c.Session().Set("flash", "Please log in first")
+				c.Flash("error", "Please log in first")
 				c.Redirect("/login")
 				return h.Div()
 			}
 
-			flash := c.Session().PopString("flash")
-			var flashMsg h.H
-			if flash != "" {
-				flashMsg = h.P(h.Text(flash), h.Style("color: green"))
-			}
 			return h.Div(
-				flashMsg,
+				flashes(),
 				h.H1(h.Textf("Dashboard - Hello, %s!", username)),
 				h.P(h.Text("Your session persists across page refreshes.")),
 				h.Button(h.Text("Logout"), logout.OnClick()),
@@ -128,11 +128,9 @@ func RenderTable(rows *sql.Rows, columnClasses []string) (H, error) {
 		return nil, err
 	}
 
-	headerCells := make([]h.H, len(cols))
-	for i, col := range cols {
-		headerCells[i] = h.Th(h.Attr("scope", "col"), h.Text(col))
-	}
-	thead := h.THead(h.Tr(headerCells...))
+	thead := h.THead(h.Tr(h.Map(cols, func(col string) h.H {
+		return h.Th(h.Attr("scope", "col"), h.Text(col))
+	})))
 
 	var bodyRows []h.H
 	for rows.Next() {
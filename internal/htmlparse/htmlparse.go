@@ -0,0 +1,44 @@
+// Package htmlparse reads HTML the way h.Parse does, for the packages of
+// Via that work on golang.org/x/net/html trees.
+package htmlparse
+
+import (
+	"bytes"
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Parse reads HTML and returns the parsed nodes. A full document, starting
+// with a doctype or <html>, is returned as its document node; anything else
+// is read as the content of a body element and returned as the top level
+// nodes.
+func Parse(r io.Reader) ([]*html.Node, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if isDocument(src) {
+		doc, err := html.Parse(bytes.NewReader(src))
+		if err != nil {
+			return nil, err
+		}
+		return []*html.Node{doc}, nil
+	}
+	return html.ParseFragment(bytes.NewReader(src), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+}
+
+// isDocument reports whether src starts with a doctype or an html element.
+func isDocument(src []byte) bool {
+	src = bytes.TrimSpace(src)
+	return hasPrefixFold(src, "<!doctype") || hasPrefixFold(src, "<html")
+}
+
+func hasPrefixFold(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && bytes.EqualFold(b[:len(prefix)], []byte(prefix))
+}
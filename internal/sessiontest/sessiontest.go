@@ -0,0 +1,116 @@
+// Package sessiontest provides a conformance suite for scs session stores
+// shipped with Via.
+package sessiontest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory creates an empty store that removes expired entries every cleanup
+// interval. stored counts the entries physically held by the store, expired
+// or not; it may be nil if the store does not expose them, which skips the
+// cleanup check.
+type Factory func(t *testing.T, cleanup time.Duration) (store scs.Store, stored func() int)
+
+// Run checks that the stores made by newStore behave as scs expects.
+func Run(t *testing.T, newStore Factory) {
+	t.Run("FindMissing", func(t *testing.T) {
+		s, _ := newStore(t, 0)
+		b, found, err := s.Find("missing")
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.Nil(t, b)
+	})
+
+	t.Run("CommitFind", func(t *testing.T) {
+		s, _ := newStore(t, 0)
+		require.NoError(t, s.Commit("tok", []byte("one"), time.Now().Add(time.Minute)))
+		b, found, err := s.Find("tok")
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []byte("one"), b)
+
+		require.NoError(t, s.Commit("tok", []byte("two"), time.Now().Add(time.Minute)))
+		b, _, err = s.Find("tok")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("two"), b, "commit should overwrite existing data")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		s, _ := newStore(t, 0)
+		require.NoError(t, s.Commit("tok", []byte("data"), time.Now().Add(time.Minute)))
+		require.NoError(t, s.Delete("tok"))
+		_, found, err := s.Find("tok")
+		require.NoError(t, err)
+		assert.False(t, found)
+		assert.NoError(t, s.Delete("tok"), "deleting a missing token should be a no-op")
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		s, _ := newStore(t, 0)
+		require.NoError(t, s.Commit("tok", []byte("data"), time.Now().Add(-time.Second)))
+		_, found, err := s.Find("tok")
+		require.NoError(t, err)
+		assert.False(t, found, "expired sessions should not be found")
+	})
+
+	t.Run("All", func(t *testing.T) {
+		s, _ := newStore(t, 0)
+		it, ok := s.(scs.IterableStore)
+		if !ok {
+			t.Skip("store is not iterable")
+		}
+		all, err := it.All()
+		require.NoError(t, err)
+		assert.NotNil(t, all)
+		assert.Empty(t, all)
+
+		require.NoError(t, s.Commit("live", []byte("a"), time.Now().Add(time.Minute)))
+		require.NoError(t, s.Commit("dead", []byte("b"), time.Now().Add(-time.Second)))
+		all, err = it.All()
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"live": []byte("a")}, all)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		s, stored := newStore(t, 50*time.Millisecond)
+		if stored == nil {
+			t.Skip("store does not expose its entries")
+		}
+		require.NoError(t, s.Commit("live", []byte("a"), time.Now().Add(time.Minute)))
+		require.NoError(t, s.Commit("dead", []byte("b"), time.Now().Add(20*time.Millisecond)))
+		require.Equal(t, 2, stored())
+		assert.Eventually(t, func() bool { return stored() == 1 }, 2*time.Second, 10*time.Millisecond,
+			"expired entries should be removed by cleanup")
+		_, found, err := s.Find("live")
+		require.NoError(t, err)
+		assert.True(t, found)
+	})
+
+	t.Run("SessionManager", func(t *testing.T) {
+		s, _ := newStore(t, 0)
+		sm := scs.New()
+		sm.Store = s
+
+		ctx, err := sm.Load(context.Background(), "")
+		require.NoError(t, err)
+		sm.Put(ctx, "user", "alice")
+		token, _, err := sm.Commit(ctx)
+		require.NoError(t, err)
+
+		ctx, err = sm.Load(context.Background(), token)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", sm.GetString(ctx, "user"))
+
+		require.NoError(t, sm.Destroy(ctx))
+		_, found, err := s.Find(token)
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/ryanhamamura/via/h"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -193,3 +194,23 @@ func TestPubSub_NoOpDuringPanicCheck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, sub)
 }
+
+type loggingMockPubSub struct {
+	*mockPubSub
+	logger *zerolog.Logger
+}
+
+func (m *loggingMockPubSub) SetLogger(l zerolog.Logger) {
+	m.logger = &l
+}
+
+func TestPubSub_ReceivesAppLogger(t *testing.T) {
+	ps := &loggingMockPubSub{mockPubSub: newMockPubSub()}
+	v := New()
+	v.Config(Options{PubSub: ps})
+	require.NotNil(t, ps.logger)
+
+	custom := zerolog.Nop()
+	v.Config(Options{Logger: &custom})
+	assert.Equal(t, custom, *ps.logger, "reconfiguring the logger should reach the backend")
+}
@@ -0,0 +1,131 @@
+package via
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Policy decides whether a user holds a permission such as "orders:delete".
+// The user is nil for anonymous visitors.
+type Policy interface {
+	Allow(u *User, permission string) bool
+}
+
+// PolicyFunc adapts a function to a Policy.
+type PolicyFunc func(u *User, permission string) bool
+
+// Allow calls f.
+func (f PolicyFunc) Allow(u *User, permission string) bool { return f(u, permission) }
+
+// RolePermissions is a Policy that grants each role a list of permissions.
+// A permission ending in ":*" grants every permission with that prefix, and
+// "*" grants everything.
+//
+// Example:
+//
+//	v.Config(via.Options{Policy: via.RolePermissions{
+//		"admin": {"*"},
+//		"staff": {"orders:*", "customers:read"},
+//	}})
+type RolePermissions map[string][]string
+
+// Allow reports whether any role of u grants permission.
+func (rp RolePermissions) Allow(u *User, permission string) bool {
+	if u == nil {
+		return false
+	}
+	for _, role := range u.Roles {
+		if slices.ContainsFunc(rp[role], func(p string) bool { return grants(p, permission) }) {
+			return true
+		}
+	}
+	return false
+}
+
+func grants(p, permission string) bool {
+	if p == "*" || p == permission {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(p, "*")
+	return ok && strings.HasSuffix(prefix, ":") && strings.HasPrefix(permission, prefix)
+}
+
+// rolePolicy is the default Policy: a permission is held by users with a
+// role of the same name.
+var rolePolicy = PolicyFunc(func(u *User, permission string) bool { return u.HasRole(permission) })
+
+// AuditEvent records a request denied for lack of a permission.
+type AuditEvent struct {
+	Time       time.Time
+	User       *User // nil for anonymous visitors
+	Permission string
+	Route      string
+	Action     string // action id; empty when a page was denied
+	RemoteAddr string
+}
+
+// WithPermission returns an ActionOption that only runs the action for
+// users holding permission. Other calls are answered with 403 and reported
+// through Options.Audit. Use Context.Can to hide the controls as well.
+//
+// Example:
+//
+//	del := c.Action(deleteOrder, via.WithPermission("orders:delete"))
+func WithPermission(permission string) ActionOption {
+	return func(e *actionEntry) {
+		e.permission = permission
+	}
+}
+
+// RequirePermission returns a guard that answers 403 to visitors without
+// permission. Put it after a guard that signs users in, such as
+// auth.RequireAuth, so anonymous visitors are sent to log in first.
+//
+// Example:
+//
+//	admin := v.Group("/admin", a.RequireAuth(), v.RequirePermission("admin"))
+func (v *V) RequirePermission(permission string) Guard {
+	return func(w http.ResponseWriter, r *http.Request) bool {
+		u := v.Session(r).User()
+		if v.allow(u, permission) {
+			return true
+		}
+		v.denied(nil, AuditEvent{User: u, Permission: permission, Route: r.URL.Path, RemoteAddr: r.RemoteAddr})
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+}
+
+// Can reports whether the user of the page holds permission, e.g. to only
+// render the buttons of actions registered with WithPermission.
+func (c *Context) Can(permission string) bool {
+	if c.id == "" {
+		return true
+	}
+	return c.app.allow(c.User(), permission)
+}
+
+func (v *V) allow(u *User, permission string) bool {
+	if v.cfg.Policy == nil {
+		return rolePolicy.Allow(u, permission)
+	}
+	return v.cfg.Policy.Allow(u, permission)
+}
+
+func (v *V) denied(c *Context, e AuditEvent) {
+	e.Time = time.Now()
+	var who string
+	if e.User != nil {
+		who = e.User.ID
+	}
+	if e.Action != "" {
+		v.logWarn(c, "action '%s' denied: user '%s' lacks permission '%s'", e.Action, who, e.Permission)
+	} else {
+		v.logWarn(c, "page '%s' denied: user '%s' lacks permission '%s'", e.Route, who, e.Permission)
+	}
+	if v.cfg.Audit != nil {
+		v.cfg.Audit(e)
+	}
+}
@@ -0,0 +1,123 @@
+package via
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolePermissions(t *testing.T) {
+	p := RolePermissions{
+		"admin": {"*"},
+		"staff": {"orders:*", "customers:read"},
+	}
+	staff := &User{ID: "s", Roles: []string{"staff"}}
+	admin := &User{ID: "a", Roles: []string{"admin"}}
+
+	assert.True(t, p.Allow(staff, "orders:delete"))
+	assert.True(t, p.Allow(staff, "customers:read"))
+	assert.False(t, p.Allow(staff, "customers:delete"))
+	assert.False(t, p.Allow(staff, "ordersx:read"))
+	assert.True(t, p.Allow(admin, "customers:delete"))
+	assert.False(t, p.Allow(nil, "orders:read"))
+	assert.False(t, p.Allow(&User{ID: "n"}, "orders:read"))
+}
+
+func TestWithPermission_DeniesAndAudits(t *testing.T) {
+	v := New()
+	var audits []AuditEvent
+	v.Config(Options{
+		Policy: RolePermissions{"admin": {"orders:*"}},
+		Audit:  func(e AuditEvent) { audits = append(audits, e) },
+	})
+	deleted := 0
+	canInAction := false
+	var del *actionTrigger
+	v.Page("/orders", func(c *Context) {
+		del = c.Action(func() {
+			deleted++
+			canInAction = c.Can("orders:delete")
+		}, WithPermission("orders:delete"))
+		c.View(func() h.H {
+			if c.Can("orders:delete") {
+				return h.Button(h.Text("Delete"), del.OnClick())
+			}
+			return h.Div()
+		})
+	})
+	handler := v.sessionManager.LoadAndSave(v.mux)
+
+	ctx, token := newStoredSession(t, v.sessionManager)
+	require.NoError(t, v.Session(httptest.NewRequest("GET", "/", nil).WithContext(ctx)).SetUser(&User{ID: "u1", Roles: []string{"staff"}}))
+	_, _, err := v.sessionManager.Commit(ctx)
+	require.NoError(t, err)
+	cookie := &http.Cookie{Name: v.sessionManager.Cookie.Name, Value: token}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", target, nil)
+		r.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/orders")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "Delete", "button hidden without permission")
+	c := v.ContextsForSession(token)[0]
+
+	sigs, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+	w = get("/_action/" + del.id + "?datastar=" + url.QueryEscape(string(sigs)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Zero(t, deleted)
+	require.Len(t, audits, 1)
+	assert.Equal(t, "u1", audits[0].User.ID)
+	assert.Equal(t, "orders:delete", audits[0].Permission)
+	assert.Equal(t, "/orders", audits[0].Route)
+	assert.Equal(t, del.id, audits[0].Action)
+
+	// the check uses the user of the request's session, not a cached copy
+	require.NoError(t, v.Session(httptest.NewRequest("GET", "/", nil).WithContext(ctx)).SetUser(&User{ID: "u1", Roles: []string{"admin"}}))
+	_, _, err = v.sessionManager.Commit(ctx)
+	require.NoError(t, err)
+	w = get("/_action/" + del.id + "?datastar=" + url.QueryEscape(string(sigs)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, deleted)
+	assert.True(t, canInAction, "c.Can should agree with the action check")
+	assert.Len(t, audits, 1)
+}
+
+func TestRequirePermission(t *testing.T) {
+	v := New()
+	var audits []AuditEvent
+	v.Config(Options{Audit: func(e AuditEvent) { audits = append(audits, e) }})
+	v.Page("/admin", func(c *Context) {
+		c.View(func() h.H { return h.Text("admin area") })
+	}, v.RequirePermission("admin"))
+	handler := v.sessionManager.LoadAndSave(v.mux)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	require.Len(t, audits, 1)
+	assert.Nil(t, audits[0].User)
+	assert.Equal(t, "/admin", audits[0].Route)
+	assert.Empty(t, audits[0].Action)
+
+	ctx, token := newStoredSession(t, v.sessionManager)
+	require.NoError(t, v.Session(httptest.NewRequest("GET", "/", nil).WithContext(ctx)).SetUser(&User{ID: "u1", Roles: []string{"admin"}}))
+	_, _, err := v.sessionManager.Commit(ctx)
+	require.NoError(t, err)
+	r := httptest.NewRequest("GET", "/admin", nil)
+	r.AddCookie(&http.Cookie{Name: v.sessionManager.Cookie.Name, Value: token})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "admin area")
+}
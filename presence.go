@@ -0,0 +1,355 @@
+package via
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	presenceSubject   = "via.presence"
+	presenceHeartbeat = 10 * time.Second
+)
+
+// PresenceMember describes a live context present on a topic.
+type PresenceMember struct {
+	// ID is the id of the member's page context.
+	ID string `json:"id"`
+	// UserID is the id of the signed-in user of the member, if any. Members
+	// with the same UserID are tabs or devices of one user.
+	UserID string `json:"user_id,omitempty"`
+	// Meta holds the user metadata returned by Options.PresenceMeta.
+	Meta     map[string]string `json:"meta,omitempty"`
+	JoinedAt time.Time         `json:"joined_at"`
+}
+
+// Presence is a handle to the members of a presence topic.
+type Presence struct {
+	topic   string
+	tracker *presenceTracker
+}
+
+// Topic returns the presence topic.
+func (p *Presence) Topic() string {
+	return p.topic
+}
+
+// Members returns everyone present on the topic across all instances,
+// ordered by join time.
+func (p *Presence) Members() []PresenceMember {
+	return p.tracker.members(p.topic)
+}
+
+// Count returns the number of members present on the topic. A browser
+// with several open tabs counts once per tab.
+func (p *Presence) Count() int {
+	return len(p.Members())
+}
+
+type presenceMsg struct {
+	Op       string                      `json:"op"` // hello, state, bye
+	Instance string                      `json:"instance"`
+	Full     bool                        `json:"full,omitempty"`
+	Topics   map[string][]PresenceMember `json:"topics,omitempty"`
+}
+
+type remoteInstance struct {
+	seen   time.Time
+	topics map[string][]PresenceMember
+}
+
+// presenceTracker keeps the members of every topic. Members of this
+// instance are replicated to other instances through PubSub, which in turn
+// announce theirs. Instances that stop sending heartbeats are dropped.
+type presenceTracker struct {
+	app       *V
+	instance  string
+	heartbeat time.Duration
+	mu        sync.Mutex
+	local     map[string]map[string]PresenceMember
+	remote    map[string]*remoteInstance
+	watchers  map[string]map[*Context]struct{}
+	startOnce sync.Once
+	stop      chan struct{}
+	sub       Subscription
+}
+
+func (v *V) presenceTracker() *presenceTracker {
+	v.presenceOnce.Do(func() {
+		v.presence = &presenceTracker{
+			app:       v,
+			instance:  genRandID(),
+			heartbeat: presenceHeartbeat,
+			local:     make(map[string]map[string]PresenceMember),
+			remote:    make(map[string]*remoteInstance),
+			watchers:  make(map[string]map[*Context]struct{}),
+			stop:      make(chan struct{}),
+		}
+	})
+	return v.presence
+}
+
+func (t *presenceTracker) start() {
+	t.startOnce.Do(func() {
+		ps := t.app.pubsub
+		if ps == nil {
+			return
+		}
+		sub, err := ps.Subscribe(presenceSubject, t.handle)
+		if err != nil {
+			t.app.logErr(nil, "presence subscribe failed: %v", err)
+			return
+		}
+		t.sub = sub
+		t.publish(presenceMsg{Op: "hello"})
+		t.publishState(true, nil)
+		go t.run()
+	})
+}
+
+func (t *presenceTracker) run() {
+	ticker := time.NewTicker(t.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			t.publishState(true, nil)
+			t.expireRemotes()
+		}
+	}
+}
+
+// close announces this instance is leaving and stops replication.
+func (t *presenceTracker) close() {
+	select {
+	case <-t.stop:
+		return
+	default:
+		close(t.stop)
+	}
+	if t.sub != nil {
+		t.publish(presenceMsg{Op: "bye"})
+		t.sub.Unsubscribe()
+	}
+}
+
+func (t *presenceTracker) join(c *Context, topic string) {
+	t.start()
+	page := c.pageCtx()
+	m := PresenceMember{
+		ID:       page.id,
+		JoinedAt: time.Now(),
+	}
+	if u := c.User(); u != nil {
+		m.UserID = u.ID
+	}
+	if fn := t.app.cfg.PresenceMeta; fn != nil {
+		m.Meta = fn(c.Session())
+	}
+
+	t.mu.Lock()
+	if t.local[topic] == nil {
+		t.local[topic] = make(map[string]PresenceMember)
+	}
+	_, rejoined := t.local[topic][m.ID]
+	if !rejoined {
+		t.local[topic][m.ID] = m
+	}
+	if t.watchers[topic] == nil {
+		t.watchers[topic] = make(map[*Context]struct{})
+	}
+	t.watchers[topic][c] = struct{}{}
+	t.mu.Unlock()
+
+	c.OnDispose(func() { t.leave(c, topic) })
+	if !rejoined {
+		t.publishState(false, []string{topic})
+		t.notify(topic)
+	}
+}
+
+func (t *presenceTracker) leave(c *Context, topic string) {
+	id := c.pageCtx().id
+	t.mu.Lock()
+	delete(t.watchers[topic], c)
+	if len(t.watchers[topic]) == 0 {
+		delete(t.watchers, topic)
+	}
+	_, present := t.local[topic][id]
+	delete(t.local[topic], id)
+	if len(t.local[topic]) == 0 {
+		delete(t.local, topic)
+	}
+	t.mu.Unlock()
+
+	if present {
+		t.publishState(false, []string{topic})
+		t.notify(topic)
+	}
+}
+
+func (t *presenceTracker) members(topic string) []PresenceMember {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []PresenceMember
+	for _, m := range t.local[topic] {
+		out = append(out, m)
+	}
+	for _, r := range t.remote {
+		out = append(out, r.topics[topic]...)
+	}
+	slices.SortFunc(out, func(a, b PresenceMember) int {
+		if c := a.JoinedAt.Compare(b.JoinedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return out
+}
+
+// notify re-syncs every connected context watching topic.
+func (t *presenceTracker) notify(topic string) {
+	t.mu.Lock()
+	var watchers []*Context
+	for c := range t.watchers[topic] {
+		if c.pageCtx().sseConnected.Load() {
+			watchers = append(watchers, c)
+		}
+	}
+	t.mu.Unlock()
+	for _, c := range watchers {
+		go t.app.runOnContext(c, func(c *Context) { c.Sync() })
+	}
+}
+
+func (t *presenceTracker) publish(msg presenceMsg) {
+	ps := t.app.pubsub
+	if ps == nil || t.sub == nil {
+		return
+	}
+	msg.Instance = t.instance
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if err := ps.Publish(presenceSubject, data); err != nil {
+		t.app.logWarn(nil, "presence publish failed: %v", err)
+	}
+}
+
+// publishState announces the local members of the given topics, or of all
+// topics if full is set.
+func (t *presenceTracker) publishState(full bool, topics []string) {
+	t.mu.Lock()
+	if full {
+		topics = topics[:0]
+		for topic := range t.local {
+			topics = append(topics, topic)
+		}
+	}
+	state := make(map[string][]PresenceMember, len(topics))
+	for _, topic := range topics {
+		members := []PresenceMember{}
+		for _, m := range t.local[topic] {
+			members = append(members, m)
+		}
+		state[topic] = members
+	}
+	t.mu.Unlock()
+	t.publish(presenceMsg{Op: "state", Full: full, Topics: state})
+}
+
+func (t *presenceTracker) handle(data []byte) {
+	var msg presenceMsg
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Instance == t.instance {
+		return
+	}
+	switch msg.Op {
+	case "hello":
+		t.publishState(true, nil)
+	case "bye":
+		t.mu.Lock()
+		r := t.remote[msg.Instance]
+		delete(t.remote, msg.Instance)
+		t.mu.Unlock()
+		if r != nil {
+			for topic := range r.topics {
+				t.notify(topic)
+			}
+		}
+	case "state":
+		t.applyState(msg)
+	}
+}
+
+func (t *presenceTracker) applyState(msg presenceMsg) {
+	t.mu.Lock()
+	r := t.remote[msg.Instance]
+	if r == nil {
+		r = &remoteInstance{topics: make(map[string][]PresenceMember)}
+		t.remote[msg.Instance] = r
+	}
+	r.seen = time.Now()
+	var changed []string
+	if msg.Full {
+		for topic := range r.topics {
+			if _, ok := msg.Topics[topic]; !ok {
+				delete(r.topics, topic)
+				changed = append(changed, topic)
+			}
+		}
+	}
+	for topic, members := range msg.Topics {
+		if !sameMembers(r.topics[topic], members) {
+			changed = append(changed, topic)
+		}
+		if len(members) == 0 {
+			delete(r.topics, topic)
+		} else {
+			r.topics[topic] = members
+		}
+	}
+	t.mu.Unlock()
+	for _, topic := range changed {
+		t.notify(topic)
+	}
+}
+
+// expireRemotes drops instances that missed three heartbeats.
+func (t *presenceTracker) expireRemotes() {
+	deadline := time.Now().Add(-3 * t.heartbeat)
+	t.mu.Lock()
+	var changed []string
+	for id, r := range t.remote {
+		if r.seen.Before(deadline) {
+			for topic := range r.topics {
+				changed = append(changed, topic)
+			}
+			delete(t.remote, id)
+		}
+	}
+	t.mu.Unlock()
+	for _, topic := range changed {
+		t.notify(topic)
+	}
+}
+
+func sameMembers(a, b []PresenceMember) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := make(map[string]struct{}, len(a))
+	for _, m := range a {
+		ids[m.ID] = struct{}{}
+	}
+	for _, m := range b {
+		if _, ok := ids[m.ID]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package via
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPresenceCtx(v *V, id string) *Context {
+	c := newContext(id, "/tickets/{id}", v)
+	c.injectRouteParams(map[string]string{"id": "42"})
+	c.View(func() h.H { return h.Div() })
+	v.registerCtx(c)
+	return c
+}
+
+func TestPresence_DefaultTopicIsPagePath(t *testing.T) {
+	v := New()
+	c := newPresenceCtx(v, "p1")
+	p := c.Presence("")
+	assert.Equal(t, "/tickets/42", p.Topic())
+}
+
+func TestPresence_JoinAndLeaveOnDispose(t *testing.T) {
+	v := New()
+	c1 := newPresenceCtx(v, "p1")
+	c2 := newPresenceCtx(v, "p2")
+
+	p1 := c1.Presence("")
+	c2.Presence("")
+	c2.Presence("") // joining twice is idempotent
+	assert.Equal(t, 2, p1.Count())
+
+	v.cleanupCtx(c2)
+	require.Equal(t, 1, p1.Count())
+	assert.Equal(t, "p1", p1.Members()[0].ID)
+}
+
+func TestPresence_Meta(t *testing.T) {
+	v := New()
+	v.Config(Options{PresenceMeta: func(s *Session) map[string]string {
+		return map[string]string{"name": "alice"}
+	}})
+	c := newPresenceCtx(v, "p1")
+	p := c.Presence("room")
+	require.Len(t, p.Members(), 1)
+	assert.Equal(t, "alice", p.Members()[0].Meta["name"])
+}
+
+func TestPresence_UserID(t *testing.T) {
+	v := New()
+	c1 := newPresenceCtx(v, "p1")
+	c1.setSessionKey("secret-token")
+	c1.cacheUser(&User{ID: "u1"})
+	c2 := newPresenceCtx(v, "p2")
+
+	p := c1.Presence("room")
+	c2.Presence("room")
+	members := p.Members()
+	require.Len(t, members, 2)
+	assert.Equal(t, "u1", members[0].UserID)
+	assert.Empty(t, members[1].UserID)
+
+	b, err := json.Marshal(members)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "secret-token", "session tokens must not be shared")
+}
+
+func TestPresence_NoOpDuringPanicCheck(t *testing.T) {
+	v := New()
+	c := newContext("", "/", v)
+	p := c.Presence("room")
+	assert.Equal(t, 0, p.Count())
+}
+
+func TestPresence_AcrossInstances(t *testing.T) {
+	ps := newMockPubSub()
+	v1 := New()
+	v1.Config(Options{PubSub: ps})
+	v2 := New()
+	v2.Config(Options{PubSub: ps})
+
+	a := newPresenceCtx(v1, "a")
+	pa := a.Presence("")
+	b := newPresenceCtx(v2, "b")
+	pb := b.Presence("")
+
+	assert.Equal(t, 2, pa.Count(), "instance 1 should see the member of instance 2")
+	assert.Equal(t, 2, pb.Count(), "instance 2 should see the member of instance 1")
+
+	v2.cleanupCtx(b)
+	assert.Equal(t, 1, pa.Count())
+
+	c := newPresenceCtx(v2, "c")
+	c.Presence("")
+	assert.Equal(t, 2, pa.Count())
+
+	v2.Shutdown()
+	assert.Equal(t, 1, pa.Count(), "members of a stopped instance should be dropped")
+}
+
+func TestPresence_ExpiresSilentInstances(t *testing.T) {
+	ps := newMockPubSub()
+	v1 := New()
+	v1.Config(Options{PubSub: ps})
+	v2 := New()
+	v2.Config(Options{PubSub: ps})
+
+	newPresenceCtx(v1, "a").Presence("room")
+	pb := newPresenceCtx(v2, "b").Presence("room")
+	require.Equal(t, 2, pb.Count())
+
+	tr := v2.presenceTracker()
+	tr.mu.Lock()
+	for _, r := range tr.remote {
+		r.seen = r.seen.Add(-4 * tr.heartbeat)
+	}
+	tr.mu.Unlock()
+	tr.expireRemotes()
+	assert.Equal(t, 1, pb.Count())
+}
@@ -1,5 +1,7 @@
 package via
 
+import "github.com/rs/zerolog"
+
 // PubSub is an interface for publish/subscribe messaging backends.
 // The vianats sub-package provides an embedded NATS implementation.
 type PubSub interface {
@@ -12,3 +14,9 @@ type PubSub interface {
 type Subscription interface {
 	Unsubscribe() error
 }
+
+// pubsubLogger is implemented by PubSub backends that report connection
+// events. Via hands them the application logger on Config.
+type pubsubLogger interface {
+	SetLogger(l zerolog.Logger)
+}
@@ -1,6 +1,18 @@
 package via
 
-import "golang.org/x/time/rate"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
 
 const (
 	defaultActionRate  float64 = 10.0
@@ -12,14 +24,177 @@ const (
 type RateLimitConfig struct {
 	Rate  float64
 	Burst int
+
+	// Key shares one bucket between all requests of a client, e.g.
+	// KeyByIP or KeyBySession, so opening more tabs does not raise the
+	// limit. Buckets live in Options.LimiterStore. Nil limits actions per
+	// context, and page loads and SSE connects by IP.
+	Key RateLimitKey
+}
+
+// RateLimitKey identifies the client of a request for rate limiting.
+type RateLimitKey func(v *V, r *http.Request) string
+
+// KeyByIP limits each client IP address; see V.ClientIP.
+func KeyByIP(v *V, r *http.Request) string {
+	return "ip:" + v.ClientIP(r)
+}
+
+// KeyBySession limits each session, and requests without one by IP. The
+// key holds a hash of the session token, not the token, since stores such
+// as vianats.LimiterStore share keys with other processes.
+func KeyBySession(v *V, r *http.Request) string {
+	if token := v.sessionToken(r.Context()); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return "session:" + hex.EncodeToString(sum[:])
+	}
+	return KeyByIP(v, r)
+}
+
+// KeyByUser limits each signed-in user across all of their sessions, and
+// anonymous requests by session.
+func KeyByUser(v *V, r *http.Request) string {
+	if u := v.Session(r).User(); u != nil {
+		return "user:" + u.ID
+	}
+	return KeyBySession(v, r)
+}
+
+// LimiterStore holds the token buckets of keyed rate limits. Use
+// vianats.NewLimiterStore to share buckets between instances.
+type LimiterStore interface {
+	// Allow takes a token from the bucket of key, which refills at rate
+	// tokens per second up to burst, and reports whether one was left.
+	// Via passes a burst of at least one.
+	Allow(key string, rate float64, burst int) (bool, error)
+}
+
+// memoryLimiterStore is the default LimiterStore, local to the instance.
+type memoryLimiterStore struct {
+	mu        sync.Mutex
+	limiters  map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+func newMemoryLimiterStore() *memoryLimiterStore {
+	return &memoryLimiterStore{limiters: make(map[string]*rate.Limiter), lastSweep: time.Now()}
+}
+
+func (s *memoryLimiterStore) Allow(key string, r float64, burst int) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) > time.Minute {
+		// full buckets hold no state worth keeping
+		for k, l := range s.limiters {
+			if l.TokensAt(now) >= float64(l.Burst()) {
+				delete(s.limiters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	l, ok := s.limiters[key]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(r), burst)
+		s.limiters[key] = l
+	}
+	return l.AllowN(now, 1), nil
+}
+
+// contextLimiter returns the action limiter of a new context, or nil if
+// actions are limited per client key or not at all.
+func (v *V) contextLimiter() *rate.Limiter {
+	if v.actionRateLimit.Key != nil {
+		return nil
+	}
+	return newLimiter(v.actionRateLimit, defaultActionRate, defaultActionBurst)
+}
+
+// allowAction applies a keyed ActionRateLimit to r.
+func (v *V) allowAction(r *http.Request) bool {
+	cfg, ok := withDefaults(v.actionRateLimit, defaultActionRate, defaultActionBurst)
+	return !ok || cfg.Key == nil || v.allowRequest("action", cfg, r)
+}
+
+// allowRequest applies the keyed limit cfg of scope ("page", "sse" or
+// "action") to r. A zero Burst allows a second's worth of requests at once,
+// at least one. Store errors let the request through.
+func (v *V) allowRequest(scope string, cfg RateLimitConfig, r *http.Request) bool {
+	if cfg.Burst <= 0 {
+		cfg.Burst = max(1, int(math.Ceil(cfg.Rate)))
+	}
+	key := cfg.Key
+	if key == nil {
+		key = KeyByIP
+	}
+	ok, err := v.limiterStore().Allow(scope+":"+key(v, r), cfg.Rate, cfg.Burst)
+	if err != nil {
+		v.logWarn(nil, "rate limiter store failed: %v", err)
+		return true
+	}
+	return ok
+}
+
+func (v *V) limiterStore() LimiterStore {
+	if v.cfg.LimiterStore != nil {
+		return v.cfg.LimiterStore
+	}
+	v.limitersOnce.Do(func() { v.limiters = newMemoryLimiterStore() })
+	return v.limiters
+}
+
+// ClientIP returns the IP address of the client of r. Behind proxies listed
+// in Options.TrustedProxies it is taken from the X-Forwarded-For or
+// X-Real-IP header: the rightmost address not belonging to a trusted proxy.
+func (v *V) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !v.trustedProxy(host) {
+		return host
+	}
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			host = hop
+			if !v.trustedProxy(hop) {
+				break
+			}
+		}
+		return host
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return host
+}
+
+func (v *V) trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range v.cfg.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
 }
 
 // ActionOption configures per-action behaviour when passed to Context.Action.
 type ActionOption func(*actionEntry)
 
 type actionEntry struct {
-	fn      func()
-	limiter *rate.Limiter // nil = use context default
+	fn         func()
+	limiter    *rate.Limiter // nil = use context default
+	permission string        // empty = anyone
 }
 
 // WithRateLimit returns an ActionOption that gives this action its own
@@ -33,16 +208,24 @@ func WithRateLimit(r float64, burst int) ActionOption {
 // newLimiter creates a *rate.Limiter from cfg, substituting defaults for zero
 // values. A Rate of -1 disables limiting (returns nil).
 func newLimiter(cfg RateLimitConfig, defaultRate float64, defaultBurst int) *rate.Limiter {
-	r := cfg.Rate
-	b := cfg.Burst
-	if r == -1 {
+	cfg, ok := withDefaults(cfg, defaultRate, defaultBurst)
+	if !ok {
 		return nil
 	}
-	if r == 0 {
-		r = defaultRate
+	return rate.NewLimiter(rate.Limit(cfg.Rate), cfg.Burst)
+}
+
+// withDefaults substitutes defaults for the zero values of cfg. It returns
+// false if cfg disables limiting.
+func withDefaults(cfg RateLimitConfig, defaultRate float64, defaultBurst int) (RateLimitConfig, bool) {
+	if cfg.Rate == -1 {
+		return cfg, false
+	}
+	if cfg.Rate == 0 {
+		cfg.Rate = defaultRate
 	}
-	if b == 0 {
-		b = defaultBurst
+	if cfg.Burst == 0 {
+		cfg.Burst = defaultBurst
 	}
-	return rate.NewLimiter(rate.Limit(r), b)
+	return cfg, true
 }
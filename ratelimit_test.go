@@ -1,8 +1,16 @@
 package via
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/ryanhamamura/via/h"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -99,3 +107,152 @@ func TestContextLimiter_CustomConfig(t *testing.T) {
 	assert.InDelta(t, 50.0, float64(c.actionLimiter.Limit()), 0.001)
 	assert.Equal(t, 100, c.actionLimiter.Burst())
 }
+
+func TestClientIP_TrustedProxies(t *testing.T) {
+	v := New()
+	req := func(remote, xff, realIP string) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remote
+		if xff != "" {
+			r.Header.Set("X-Forwarded-For", xff)
+		}
+		if realIP != "" {
+			r.Header.Set("X-Real-IP", realIP)
+		}
+		return r
+	}
+	assert.Equal(t, "10.0.0.1", v.ClientIP(req("10.0.0.1:1234", "203.0.113.9", "")), "no trusted proxies")
+
+	v.Config(Options{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+	assert.Equal(t, "203.0.113.9", v.ClientIP(req("10.0.0.1:1234", "203.0.113.9", "")))
+	assert.Equal(t, "203.0.113.9", v.ClientIP(req("10.0.0.1:1234", "1.1.1.1, 203.0.113.9, 10.0.0.7", "")),
+		"spoofed leftmost hops are ignored")
+	assert.Equal(t, "198.51.100.4", v.ClientIP(req("10.0.0.1:1234", "", "198.51.100.4")))
+	assert.Equal(t, "192.0.2.1", v.ClientIP(req("192.0.2.1:1234", "203.0.113.9", "")), "untrusted peer")
+}
+
+func TestMemoryLimiterStore(t *testing.T) {
+	s := newMemoryLimiterStore()
+	for i := 0; i < 3; i++ {
+		ok, err := s.Allow("a", 1, 3)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, _ := s.Allow("a", 1, 3)
+	assert.False(t, ok)
+	ok, _ = s.Allow("b", 1, 3)
+	assert.True(t, ok, "keys have their own buckets")
+}
+
+func TestActionRateLimit_SharedAcrossTabs(t *testing.T) {
+	v := New()
+	v.Config(Options{ActionRateLimit: RateLimitConfig{Rate: 0.001, Burst: 3, Key: KeyBySession}})
+	var act *actionTrigger
+	v.Page("/", func(c *Context) {
+		act = c.Action(func() {})
+		c.View(func() h.H { return h.Div() })
+	})
+	handler := v.sessionManager.LoadAndSave(v.mux)
+	_, token := newStoredSession(t, v.sessionManager)
+	get := func(target string) int {
+		r := httptest.NewRequest("GET", target, nil)
+		r.AddCookie(&http.Cookie{Name: v.sessionManager.Cookie.Name, Value: token})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	get("/")
+	get("/")
+	tabs := v.ContextsForSession(token)
+	require.Len(t, tabs, 2)
+	assert.Nil(t, tabs[0].actionLimiter, "keyed limits replace the per-context limiter")
+
+	var codes []int
+	for i := 0; i < 4; i++ {
+		c := tabs[i%2]
+		sigs, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+		codes = append(codes, get("/_action/"+act.id+"?datastar="+url.QueryEscape(string(sigs))))
+	}
+	assert.Equal(t, []int{200, 200, 200, http.StatusTooManyRequests}, codes)
+}
+
+func TestKeyBySession_HidesToken(t *testing.T) {
+	v := New()
+	ctx, token := newStoredSession(t, v.sessionManager)
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	key := KeyBySession(v, r)
+	assert.True(t, strings.HasPrefix(key, "session:"))
+	assert.NotContains(t, key, token)
+	assert.Equal(t, key, KeyBySession(v, r), "keys are stable")
+}
+
+func TestPageAndSSERateLimits(t *testing.T) {
+	v := New()
+	v.Config(Options{
+		PageRateLimit: RateLimitConfig{Rate: 0.001, Burst: 2},
+		SSERateLimit:  RateLimitConfig{Rate: 0.001, Burst: 1},
+	})
+	v.Page("/", func(c *Context) { c.View(func() h.H { return h.Div() }) })
+	get := func(target, remote string) int {
+		r := httptest.NewRequest("GET", target, nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		v.mux.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, get("/", "192.0.2.1:1"))
+	assert.Equal(t, http.StatusOK, get("/", "192.0.2.1:2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("/", "192.0.2.1:3"))
+	assert.Equal(t, http.StatusOK, get("/", "192.0.2.2:1"), "other clients are not limited")
+
+	// the first connect is let through (and fails for lack of a context)
+	assert.NotEqual(t, http.StatusTooManyRequests, get("/_sse", "192.0.2.1:4"))
+	assert.Equal(t, http.StatusTooManyRequests, get("/_sse", "192.0.2.1:5"))
+}
+
+func TestMaxContextsPerSession(t *testing.T) {
+	v := New()
+	v.Config(Options{MaxContextsPerSession: 2})
+	v.Page("/", func(c *Context) { c.View(func() h.H { return h.Div() }) })
+	handler := v.sessionManager.LoadAndSave(v.mux)
+	_, token := newStoredSession(t, v.sessionManager)
+	get := func(withSession bool) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		if withSession {
+			r.AddCookie(&http.Cookie{Name: v.sessionManager.Cookie.Name, Value: token})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, get(true))
+	assert.Equal(t, http.StatusOK, get(true))
+	assert.Equal(t, http.StatusTooManyRequests, get(true))
+	assert.Len(t, v.ContextsForSession(token), 2)
+	assert.Equal(t, http.StatusOK, get(false), "the cap is per session")
+
+	v.cleanupCtx(v.ContextsForSession(token)[0])
+	assert.Equal(t, http.StatusOK, get(true), "closing a page frees a slot")
+}
+
+func TestPageRateLimit_ZeroBurst(t *testing.T) {
+	v := New()
+	v.Config(Options{PageRateLimit: RateLimitConfig{Rate: 2}})
+	v.Page("/", func(c *Context) { c.View(func() h.H { return h.Div() }) })
+	get := func() int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "192.0.2.1:1"
+		w := httptest.NewRecorder()
+		v.mux.ServeHTTP(w, r)
+		return w.Code
+	}
+	assert.Equal(t, []int{200, 200, http.StatusTooManyRequests}, []int{get(), get(), get()},
+		"a zero burst allows a second's worth of loads")
+
+	v.Config(Options{PageRateLimit: RateLimitConfig{Rate: 0.5}})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.2:1"
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code, "rates below one still allow a first load")
+}
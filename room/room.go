@@ -0,0 +1,388 @@
+// Package room provides generic shared rooms for Via applications. A room
+// holds data of any type and a set of members that are synced, at most once
+// per interval, after the data changes.
+//
+// Example:
+//
+//	rooms := room.NewRooms[Chat, string]()
+//	lobby, err := rooms.Create("lobby")
+//
+//	v.Page("/", func(c *via.Context) {
+//		lobby.Join(c, userID) // leaves automatically when c is disposed
+//		(...)
+//	})
+package room
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ryanhamamura/via"
+)
+
+const defaultInterval = 100 * time.Millisecond
+
+// Syncable is a room member that can be told to re-render.
+// *via.Context implements it.
+type Syncable interface {
+	Sync()
+}
+
+// Option configures rooms created with NewRooms or NewRoom.
+type Option func(*options)
+
+type options struct {
+	interval time.Duration
+	pubsub   via.PubSub
+	prefix   string
+}
+
+// WithInterval sets how often a dirty room syncs its members.
+// Defaults to 100ms.
+func WithInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// WithPubSub replicates room data to other instances through ps. Data is
+// sent as JSON on the subject "<prefix>.<room name>", so room names must be
+// valid subject tokens and TR must be JSON serializable. The last update
+// wins when instances change the same room concurrently.
+func WithPubSub(ps via.PubSub, prefix string) Option {
+	return func(o *options) {
+		o.pubsub = ps
+		o.prefix = prefix
+	}
+}
+
+// Rooms is a dynamic set of named rooms.
+type Rooms[TR any, TU comparable] struct {
+	mu     sync.RWMutex
+	byName map[string]*Room[TR, TU]
+	opts   []Option
+}
+
+// NewRooms creates an empty set of rooms. The options apply to every room
+// created in the set.
+func NewRooms[TR any, TU comparable](opts ...Option) *Rooms[TR, TU] {
+	return &Rooms[TR, TU]{
+		byName: make(map[string]*Room[TR, TU]),
+		opts:   opts,
+	}
+}
+
+// Create starts a room with the given name, or returns the existing room
+// if one with that name already exists. See NewRoom for the errors.
+func (rs *Rooms[TR, TU]) Create(name string) (*Room[TR, TU], error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rm, ok := rs.byName[name]; ok {
+		return rm, nil
+	}
+	rm, err := NewRoom[TR, TU](name, rs.opts...)
+	if err != nil {
+		return nil, err
+	}
+	rs.byName[name] = rm
+	return rm, nil
+}
+
+// Get returns the room with the given name.
+func (rs *Rooms[TR, TU]) Get(name string) (*Room[TR, TU], bool) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	rm, ok := rs.byName[name]
+	return rm, ok
+}
+
+// Delete stops and removes the room with the given name. It reports
+// whether the room existed.
+func (rs *Rooms[TR, TU]) Delete(name string) bool {
+	rs.mu.Lock()
+	rm, ok := rs.byName[name]
+	delete(rs.byName, name)
+	rs.mu.Unlock()
+	if ok {
+		rm.Stop()
+	}
+	return ok
+}
+
+// Names returns the names of all rooms in sorted order.
+func (rs *Rooms[TR, TU]) Names() []string {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	names := make([]string, 0, len(rs.byName))
+	for n := range rs.byName {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Visit calls fn with the name of every room in sorted order.
+func (rs *Rooms[TR, TU]) Visit(fn func(name string)) {
+	for _, n := range rs.Names() {
+		fn(n)
+	}
+}
+
+// Stop stops and removes all rooms.
+func (rs *Rooms[TR, TU]) Stop() {
+	for _, n := range rs.Names() {
+		rs.Delete(n)
+	}
+}
+
+// Room holds shared data of type TR and members identified by TU.
+type Room[TR any, TU comparable] struct {
+	Name      string
+	data      TR
+	dataMu    sync.RWMutex
+	dirty     bool
+	outgoing  bool
+	hasData   bool
+	members   map[TU]Syncable
+	hooked    map[*via.Context]bool // contexts with a dispose hook
+	membersMu sync.RWMutex
+	interval  time.Duration
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+	pubsub    via.PubSub
+	subject   string
+	instance  string
+	sub       via.Subscription
+}
+
+type roomMsg struct {
+	Instance string          `json:"instance"`
+	Hello    bool            `json:"hello,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// NewRoom starts a standalone room. Call Stop to release it. It fails if
+// the room can not subscribe to its subject with WithPubSub.
+func NewRoom[TR any, TU comparable](name string, opts ...Option) (*Room[TR, TU], error) {
+	o := options{interval: defaultInterval}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	r := &Room[TR, TU]{
+		Name:     name,
+		members:  make(map[TU]Syncable),
+		hooked:   make(map[*via.Context]bool),
+		interval: o.interval,
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+		pubsub:   o.pubsub,
+	}
+	if r.pubsub != nil {
+		r.subject = o.prefix + "." + name
+		r.instance = randID()
+		sub, err := r.pubsub.Subscribe(r.subject, r.receive)
+		if err != nil {
+			return nil, fmt.Errorf("room: subscribe '%s': %w", r.subject, err)
+		}
+		r.sub = sub
+		r.send(roomMsg{Hello: true})
+	}
+	go r.run()
+	return r, nil
+}
+
+// UpdateData lets the calling function update the room data.
+// Is called with a write lock - so should be *fast*
+func (r *Room[TR, TU]) UpdateData(fn func(data *TR)) {
+	r.dataMu.Lock()
+	defer r.dataMu.Unlock()
+	fn(&r.data)
+	r.dirty = true
+	r.outgoing = true
+	r.hasData = true
+}
+
+// GetData returns a copy of room data.
+// Accepts an optional subset function to transform data before copying.
+func (r *Room[TR, TU]) GetData(subsetFn ...func(*TR) TR) TR {
+	r.dataMu.RLock()
+	defer r.dataMu.RUnlock()
+
+	if len(subsetFn) == 0 || subsetFn[0] == nil {
+		return r.data
+	}
+
+	tmp := r.data
+	return subsetFn[0](&tmp)
+}
+
+// Join adds c as a member identified by user. The member leaves the room
+// automatically when c is disposed. No-ops during panic-check init.
+//
+// Joining does not mark the room dirty; call c.Sync() to render the room
+// for the new member right away.
+func (r *Room[TR, TU]) Join(c *via.Context, user TU) {
+	if c.ID() == "" {
+		return
+	}
+	r.membersMu.Lock()
+	r.members[user] = c
+	first := !r.hooked[c]
+	r.hooked[c] = true
+	r.membersMu.Unlock()
+	if first {
+		c.OnDispose(func() { r.leaveContext(c) })
+	}
+}
+
+// JoinSyncable adds s as a member identified by user, replacing any
+// previous member with the same identity.
+func (r *Room[TR, TU]) JoinSyncable(user TU, s Syncable) {
+	r.membersMu.Lock()
+	defer r.membersMu.Unlock()
+	r.members[user] = s
+}
+
+// Leave removes the member identified by user.
+func (r *Room[TR, TU]) Leave(user TU) {
+	r.membersMu.Lock()
+	defer r.membersMu.Unlock()
+	delete(r.members, user)
+}
+
+// leaveContext removes the members represented by c, so a disposed tab
+// does not evict the same user's newer tab.
+func (r *Room[TR, TU]) leaveContext(c *via.Context) {
+	r.membersMu.Lock()
+	defer r.membersMu.Unlock()
+	delete(r.hooked, c)
+	for user, s := range r.members {
+		if s == c {
+			delete(r.members, user)
+		}
+	}
+}
+
+// Members returns the identities of all current members.
+func (r *Room[TR, TU]) Members() []TU {
+	r.membersMu.RLock()
+	defer r.membersMu.RUnlock()
+	users := make([]TU, 0, len(r.members))
+	for u := range r.members {
+		users = append(users, u)
+	}
+	return users
+}
+
+// Publish syncs all members now if the room data changed since the last
+// publish. Rooms publish on their own every interval; call Publish to skip
+// the wait. Members joined with Join are synced serialized with the actions
+// of their page, without waiting, as Broadcast does.
+func (r *Room[TR, TU]) Publish() {
+	r.dataMu.Lock()
+	if !r.dirty {
+		r.dataMu.Unlock()
+		return
+	}
+	r.dirty = false
+	var payload []byte
+	if r.outgoing && r.sub != nil {
+		payload, _ = json.Marshal(r.data)
+	}
+	r.outgoing = false
+	r.dataMu.Unlock()
+
+	if payload != nil {
+		r.send(roomMsg{Data: payload})
+	}
+
+	r.membersMu.RLock()
+	members := make([]Syncable, 0, len(r.members))
+	for _, s := range r.members {
+		members = append(members, s)
+	}
+	r.membersMu.RUnlock()
+
+	// Now call Sync without holding the lock
+	for _, s := range members {
+		if c, ok := s.(*via.Context); ok {
+			go c.Run(func(c *via.Context) { c.Sync() })
+			continue
+		}
+		s.Sync()
+	}
+}
+
+// Stop stops the room and waits for its publish loop to exit.
+func (r *Room[TR, TU]) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		<-r.done
+		if r.sub != nil {
+			r.sub.Unsubscribe()
+		}
+	})
+}
+
+func (r *Room[TR, TU]) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Publish()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Room[TR, TU]) send(msg roomMsg) {
+	msg.Instance = r.instance
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	_ = r.pubsub.Publish(r.subject, data)
+}
+
+// receive applies data replicated from other instances and answers
+// newcomers with the current data.
+func (r *Room[TR, TU]) receive(b []byte) {
+	var msg roomMsg
+	if err := json.Unmarshal(b, &msg); err != nil || msg.Instance == r.instance {
+		return
+	}
+	if msg.Hello {
+		r.dataMu.RLock()
+		hasData := r.hasData
+		payload, err := json.Marshal(r.data)
+		r.dataMu.RUnlock()
+		if hasData && err == nil {
+			r.send(roomMsg{Data: payload})
+		}
+		return
+	}
+	var data TR
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return
+	}
+	r.dataMu.Lock()
+	r.data = data
+	r.dirty = true
+	r.hasData = true
+	r.dataMu.Unlock()
+}
+
+func randID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,307 @@
+package room
+
+import (
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryanhamamura/via"
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Statement struct {
+	Text   string
+	Author TestUserInfo
+}
+
+type RoomData struct {
+	Convo []Statement
+}
+
+type TestUserInfo struct {
+	Name string
+}
+
+func create(t *testing.T, rooms *Rooms[RoomData, TestUserInfo], name string) *Room[RoomData, TestUserInfo] {
+	t.Helper()
+	rm, err := rooms.Create(name)
+	require.NoError(t, err)
+	return rm
+}
+
+func newRoom(t *testing.T, name string, opts ...Option) *Room[RoomData, TestUserInfo] {
+	t.Helper()
+	rm, err := NewRoom[RoomData, TestUserInfo](name, opts...)
+	require.NoError(t, err)
+	return rm
+}
+
+func TestRoomsZero(t *testing.T) {
+	rooms := NewRooms[RoomData, TestUserInfo]()
+	assert.NotNil(t, rooms)
+	assert.Empty(t, rooms.Names())
+}
+
+func TestRoomsMany(t *testing.T) {
+	rooms := NewRooms[RoomData, TestUserInfo]()
+	defer rooms.Stop()
+	create(t, rooms, "b")
+	create(t, rooms, "a")
+	assert.Equal(t, []string{"a", "b"}, rooms.Names())
+
+	// Visit
+	seen := 0
+	rooms.Visit(func(name string) { seen++ })
+	assert.Equal(t, 2, seen)
+
+	// GetRoom fail
+	_, ok := rooms.Get("z")
+	assert.False(t, ok)
+	// GetRoom
+	rm, ok := rooms.Get("a")
+	assert.True(t, ok)
+	assert.NotNil(t, rm)
+	assert.Equal(t, "a", rm.Name)
+
+	// Create is idempotent
+	assert.Same(t, rm, create(t, rooms, "a"))
+}
+
+func TestRoomsDelete(t *testing.T) {
+	rooms := NewRooms[RoomData, TestUserInfo]()
+	create(t, rooms, "a")
+	assert.True(t, rooms.Delete("a"))
+	assert.False(t, rooms.Delete("a"))
+	_, ok := rooms.Get("a")
+	assert.False(t, ok)
+}
+
+type DummySyncable struct {
+	room        *Room[RoomData, TestUserInfo]
+	timesCalled atomic.Int32
+}
+
+func (ds *DummySyncable) Sync() {
+	// Data() hits deadlock conditions from Publish()
+	ds.room.GetData()
+	ds.timesCalled.Add(1)
+}
+
+func TestRoomJoinLeave(t *testing.T) {
+	rooms := NewRooms[RoomData, TestUserInfo](WithInterval(time.Hour))
+	defer rooms.Stop()
+	rm := create(t, rooms, "a")
+	u1 := TestUserInfo{"Bob"}
+	u1Context := DummySyncable{room: rm}
+
+	// Joining a room does *not* mark it dirty. It's on the user to call Sync() -
+	// so the user gets the update immediately.
+	rm.JoinSyncable(u1, &u1Context)
+	assert.False(t, rm.dirty)
+	assert.Equal(t, []TestUserInfo{u1}, rm.Members())
+
+	// Room Data
+	rm.UpdateData(func(data *RoomData) {
+		data.Convo = append(data.Convo, Statement{"Hello", u1})
+	})
+	assert.True(t, rm.dirty)
+
+	data := rm.GetData()
+	assert.Equal(t, 1, len(data.Convo))
+
+	// BROADCAST to connected users. Clears the dirty flag.
+	rm.Publish()
+	assert.False(t, rm.dirty)
+	assert.Equal(t, int32(1), u1Context.timesCalled.Load())
+
+	// Publishing a clean room syncs nobody
+	rm.Publish()
+	assert.Equal(t, int32(1), u1Context.timesCalled.Load())
+
+	// Leave
+	rm.Leave(u1)
+	assert.Empty(t, rm.Members())
+}
+
+func TestRoomThrottledPublish(t *testing.T) {
+	rm := newRoom(t, "a", WithInterval(10*time.Millisecond))
+	defer rm.Stop()
+	ds := DummySyncable{room: rm}
+	rm.JoinSyncable(TestUserInfo{"Bob"}, &ds)
+
+	for i := 0; i < 5; i++ {
+		rm.UpdateData(func(data *RoomData) {
+			data.Convo = append(data.Convo, Statement{Text: "spam"})
+		})
+	}
+	require.Eventually(t, func() bool { return ds.timesCalled.Load() > 0 }, time.Second, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(1), ds.timesCalled.Load(), "bursts of updates should sync members once")
+}
+
+func TestRoomLeavesOnContextDispose(t *testing.T) {
+	rm := newRoom(t, "a")
+	defer rm.Stop()
+
+	v := via.New()
+	v.Page("/", func(c *via.Context) {
+		rm.Join(c, TestUserInfo{"Bob"})
+		c.View(func() h.H { return h.Div() })
+	})
+	assert.Empty(t, rm.Members(), "panic-check context should not join")
+
+	v.HTTPServeMux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(t, rm.Members(), 1)
+
+	v.Shutdown()
+	assert.Empty(t, rm.Members())
+}
+
+func TestRoomJoinHooksContextOnce(t *testing.T) {
+	rm := newRoom(t, "a")
+	defer rm.Stop()
+
+	v := via.New()
+	v.Page("/", func(c *via.Context) {
+		for range 3 { // e.g. switching rooms back and forth
+			rm.Join(c, TestUserInfo{"Bob"})
+			rm.Leave(TestUserInfo{"Bob"})
+		}
+		rm.Join(c, TestUserInfo{"Ann"})
+		c.View(func() h.H { return h.Div() })
+	})
+	v.HTTPServeMux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, []TestUserInfo{{"Ann"}}, rm.Members())
+	assert.Len(t, rm.hooked, 1)
+
+	v.Shutdown()
+	assert.Empty(t, rm.Members())
+	assert.Empty(t, rm.hooked)
+}
+
+func TestRoomSyncsContextsSerialized(t *testing.T) {
+	rm := newRoom(t, "a", WithInterval(time.Hour))
+	defer rm.Stop()
+
+	v := via.New()
+	var ctx *via.Context
+	v.Page("/", func(c *via.Context) {
+		ctx = c
+		rm.Join(c, TestUserInfo{"Bob"})
+		c.View(func() h.H { return h.Div() })
+	})
+	v.HTTPServeMux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	defer v.Shutdown()
+
+	ran := make(chan struct{})
+	go ctx.Run(func(c *via.Context) {
+		rm.UpdateData(func(data *RoomData) { data.Convo = append(data.Convo, Statement{Text: "hi"}) })
+		rm.Publish() // must not deadlock on the context it runs on
+		close(ran)
+	})
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("publish from a context blocked on that context")
+	}
+}
+
+func TestNewRoomSubscribeError(t *testing.T) {
+	_, err := NewRoom[RoomData, TestUserInfo]("a", WithPubSub(failPubSub{newMemPubSub()}, "rooms"))
+	assert.ErrorContains(t, err, "rooms.a")
+
+	rooms := NewRooms[RoomData, TestUserInfo](WithPubSub(failPubSub{newMemPubSub()}, "rooms"))
+	_, err = rooms.Create("a")
+	assert.Error(t, err)
+	assert.Empty(t, rooms.Names())
+}
+
+func TestRoomReplicatesThroughPubSub(t *testing.T) {
+	ps := newMemPubSub()
+	a := NewRooms[RoomData, TestUserInfo](WithPubSub(ps, "rooms"), WithInterval(time.Hour))
+	defer a.Stop()
+	b := NewRooms[RoomData, TestUserInfo](WithPubSub(ps, "rooms"), WithInterval(time.Hour))
+	defer b.Stop()
+
+	ra := create(t, a, "go")
+	rb := create(t, b, "go")
+	ds := DummySyncable{room: rb}
+	rb.JoinSyncable(TestUserInfo{"Bob"}, &ds)
+
+	ra.UpdateData(func(data *RoomData) {
+		data.Convo = append(data.Convo, Statement{Text: "hi"})
+	})
+	ra.Publish()
+
+	assert.Len(t, rb.GetData().Convo, 1)
+	rb.Publish()
+	assert.Equal(t, int32(1), ds.timesCalled.Load())
+
+	// a late instance receives the current data on creation
+	c := NewRooms[RoomData, TestUserInfo](WithPubSub(ps, "rooms"))
+	defer c.Stop()
+	assert.Len(t, create(t, c, "go").GetData().Convo, 1)
+}
+
+// memPubSub is a synchronous in-memory via.PubSub.
+type memPubSub struct {
+	mu   sync.Mutex
+	subs map[string][]*memSub
+}
+
+type memSub struct {
+	ps      *memPubSub
+	subject string
+	fn      func([]byte)
+}
+
+func newMemPubSub() *memPubSub {
+	return &memPubSub{subs: make(map[string][]*memSub)}
+}
+
+func (m *memPubSub) Publish(subject string, data []byte) error {
+	m.mu.Lock()
+	subs := append([]*memSub(nil), m.subs[subject]...)
+	m.mu.Unlock()
+	for _, s := range subs {
+		s.fn(data)
+	}
+	return nil
+}
+
+func (m *memPubSub) Subscribe(subject string, fn func([]byte)) (via.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := &memSub{ps: m, subject: subject, fn: fn}
+	m.subs[subject] = append(m.subs[subject], s)
+	return s, nil
+}
+
+func (m *memPubSub) Close() error { return nil }
+
+// failPubSub is a via.PubSub whose subscriptions fail.
+type failPubSub struct {
+	*memPubSub
+}
+
+func (failPubSub) Subscribe(string, func([]byte)) (via.Subscription, error) {
+	return nil, errors.New("no connection")
+}
+
+func (s *memSub) Unsubscribe() error {
+	s.ps.mu.Lock()
+	defer s.ps.mu.Unlock()
+	subs := s.ps.subs[s.subject]
+	for i, x := range subs {
+		if x == s {
+			s.ps.subs[s.subject] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
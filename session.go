@@ -3,10 +3,16 @@ package via
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/alexedwards/scs/sqlite3store"
 	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"go.etcd.io/bbolt"
 )
 
 // NewSQLiteSessionManager creates a session manager using SQLite for persistence.
@@ -30,185 +36,382 @@ func NewSQLiteSessionManager(db *sql.DB) (*scs.SessionManager, error) {
 	return sm, nil
 }
 
+// NewMemorySessionManager creates a session manager that keeps sessions in
+// memory and removes expired ones every minute. Sessions are lost on
+// restart, which makes it a good fit for tests and development.
+func NewMemorySessionManager() *scs.SessionManager {
+	sm := scs.New()
+	sm.Store = memstore.New()
+	return sm
+}
+
+// NewBoltSessionManager creates a session manager that persists sessions in
+// a bbolt database file, without cgo. Expired sessions are removed every
+// five minutes. Use NewBoltStore for control over the cleanup goroutine.
+func NewBoltSessionManager(db *bbolt.DB) (*scs.SessionManager, error) {
+	store, err := NewBoltStore(db, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	sm := scs.New()
+	sm.Store = store
+	return sm, nil
+}
+
+// sessionToken returns the session token loaded into ctx by the session
+// middleware, or an empty string if there is none.
+func (v *V) sessionToken(ctx context.Context) string {
+	if v.sessionManager == nil || !sessionLoaded(v.sessionManager, ctx) {
+		return ""
+	}
+	return v.sessionManager.Token(ctx)
+}
+
+// commitSession persists the session changes of a request right away rather
+// than when LoadAndSave writes the response, so a browser redirected by the
+// request finds them. It binds c to the committed token, which is new if the
+// request started the session.
+func (v *V) commitSession(c *Context, ctx context.Context) {
+	sm := v.sessionManager
+	if sm == nil || !sessionLoaded(sm, ctx) || sm.Status(ctx) != scs.Modified {
+		return
+	}
+	token, _, err := sm.Commit(ctx)
+	if err != nil {
+		v.logErr(c, "session commit failed: %v", err)
+		return
+	}
+	c.setSessionKey(token)
+}
+
+// sessionLoaded reports whether the session middleware loaded a session
+// into ctx. scs panics on contexts it did not load.
+func sessionLoaded(sm *scs.SessionManager, ctx context.Context) (ok bool) {
+	if ctx == nil {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	sm.Status(ctx)
+	return true
+}
+
+// ErrNoSession is returned when a session is used by a context that has
+// neither a request in flight nor a stored session to fall back on, e.g. a
+// page that was loaded without the session middleware or whose session
+// expired.
+var ErrNoSession = errors.New("via: no session available")
+
+// ErrNoRequest is returned by operations that must set the session cookie,
+// such as RenewToken, when they are called outside a page load or action.
+var ErrNoRequest = errors.New("via: no request in flight")
+
 // Session provides access to the user's session data.
 // Session data persists across page views for the same browser.
+//
+// During a page load or an action the session is bound to that request and
+// changes are committed before the response, and before any Redirect the
+// action made reaches the browser. Elsewhere, e.g. in OnInterval routines,
+// Broadcast callbacks or views rendered by Sync, every read loads the
+// session from the store and every write is committed to it directly.
 type Session struct {
 	ctx     context.Context
 	manager *scs.SessionManager
+	app     *V
+	c       *Context
+	token   string
 }
 
-// Get retrieves a value from the session.
-func (s *Session) Get(key string) any {
-	if s.manager == nil || s.ctx == nil {
-		return nil
+// Session returns the session of an HTTP request served behind the session
+// middleware, for use in custom handlers and page guards.
+func (v *V) Session(r *http.Request) *Session {
+	return &Session{
+		ctx:     r.Context(),
+		manager: v.sessionManager,
+		app:     v,
 	}
-	return s.manager.Get(s.ctx, key)
 }
 
-// GetString retrieves a string value from the session.
-func (s *Session) GetString(key string) string {
-	if s.manager == nil || s.ctx == nil {
-		return ""
+// load returns the context holding the session data: the request's, or a
+// fresh load from the store for a detached session. It returns a nil
+// context if sessions are not configured.
+func (s *Session) load() (ctx context.Context, detached bool, err error) {
+	if s.manager == nil {
+		return nil, false, nil
+	}
+	if sessionLoaded(s.manager, s.ctx) {
+		return s.ctx, false, nil
+	}
+	if s.token == "" {
+		return nil, false, ErrNoSession
+	}
+	ctx, err = s.manager.Load(context.Background(), s.token)
+	if err != nil {
+		return nil, false, fmt.Errorf("via: load session: %w", err)
+	}
+	if s.manager.Token(ctx) == "" { // expired or destroyed
+		return nil, false, ErrNoSession
 	}
-	return s.manager.GetString(s.ctx, key)
+	return ctx, true, nil
 }
 
-// GetInt retrieves an int value from the session.
-func (s *Session) GetInt(key string) int {
-	if s.manager == nil || s.ctx == nil {
-		return 0
+func (s *Session) read(fn func(ctx context.Context)) {
+	if ctx, _, _ := s.load(); ctx != nil {
+		fn(ctx)
 	}
-	return s.manager.GetInt(s.ctx, key)
 }
 
-// GetBool retrieves a bool value from the session.
-func (s *Session) GetBool(key string) bool {
-	if s.manager == nil || s.ctx == nil {
-		return false
+func (s *Session) write(fn func(ctx context.Context) error) error {
+	ctx, detached, err := s.load()
+	if ctx == nil {
+		return err
+	}
+	if err := fn(ctx); err != nil {
+		return err
+	}
+	if detached && s.manager.Status(ctx) == scs.Modified {
+		if _, _, err := s.manager.Commit(ctx); err != nil {
+			return fmt.Errorf("via: commit session: %w", err)
+		}
 	}
-	return s.manager.GetBool(s.ctx, key)
+	return nil
+}
+
+func (s *Session) warn(op string, err error) {
+	if err != nil && s.app != nil {
+		s.app.logWarn(s.c, "session %s failed: %v", op, err)
+	}
+}
+
+// Err reports why the session cannot be used, or nil if it can. Without a
+// configured SessionManager the session is a no-op and Err returns nil.
+func (s *Session) Err() error {
+	_, _, err := s.load()
+	return err
+}
+
+// Get retrieves a value from the session.
+func (s *Session) Get(key string) (v any) {
+	s.read(func(ctx context.Context) { v = s.manager.Get(ctx, key) })
+	return v
+}
+
+// GetString retrieves a string value from the session.
+func (s *Session) GetString(key string) (v string) {
+	s.read(func(ctx context.Context) { v = s.manager.GetString(ctx, key) })
+	return v
+}
+
+// GetInt retrieves an int value from the session.
+func (s *Session) GetInt(key string) (v int) {
+	s.read(func(ctx context.Context) { v = s.manager.GetInt(ctx, key) })
+	return v
+}
+
+// GetBool retrieves a bool value from the session.
+func (s *Session) GetBool(key string) (v bool) {
+	s.read(func(ctx context.Context) { v = s.manager.GetBool(ctx, key) })
+	return v
 }
 
 // Set stores a value in the session.
 func (s *Session) Set(key string, val any) {
-	if s.manager == nil || s.ctx == nil {
-		return
-	}
-	s.manager.Put(s.ctx, key, val)
+	s.warn("set '"+key+"'", s.set(key, val))
+}
+
+func (s *Session) set(key string, val any) error {
+	return s.write(func(ctx context.Context) error {
+		s.manager.Put(ctx, key, val)
+		return nil
+	})
 }
 
 // Delete removes a value from the session.
 func (s *Session) Delete(key string) {
-	if s.manager == nil || s.ctx == nil {
-		return
-	}
-	s.manager.Remove(s.ctx, key)
+	s.warn("delete '"+key+"'", s.write(func(ctx context.Context) error {
+		s.manager.Remove(ctx, key)
+		return nil
+	}))
 }
 
 // Clear removes all data from the session.
 func (s *Session) Clear() error {
-	if s.manager == nil || s.ctx == nil {
-		return nil
-	}
-	return s.manager.Clear(s.ctx)
+	return s.write(func(ctx context.Context) error {
+		return s.manager.Clear(ctx)
+	})
 }
 
-// Destroy destroys the session entirely (use for logout).
+// Destroy destroys the session entirely (use for logout). Other live
+// contexts of the session, in other tabs or on other instances, are closed.
 func (s *Session) Destroy() error {
-	if s.manager == nil || s.ctx == nil {
-		return nil
+	id := s.ID()
+	err := s.write(func(ctx context.Context) error {
+		return s.manager.Destroy(ctx)
+	})
+	if err != nil || s.manager == nil {
+		return err
+	}
+	if s.c != nil {
+		s.c.cacheUser(nil)
 	}
-	return s.manager.Destroy(s.ctx)
+	s.closeOthers(id)
+	return nil
 }
 
 // RenewToken regenerates the session token (use after login to prevent session fixation).
+// Other live contexts still bound to the old token are closed. It returns
+// ErrNoRequest outside a page load or action, as the browser would not
+// receive the new token.
 func (s *Session) RenewToken() error {
-	if s.manager == nil || s.ctx == nil {
-		return nil
+	ctx, detached, err := s.load()
+	if ctx == nil {
+		return err
+	}
+	if detached {
+		return fmt.Errorf("via: renew session token: %w", ErrNoRequest)
 	}
-	return s.manager.RenewToken(s.ctx)
+	id := s.ID()
+	if err := s.manager.RenewToken(ctx); err != nil {
+		return err
+	}
+	if s.c != nil {
+		s.c.setSessionKey(s.ID())
+	}
+	s.closeOthers(id)
+	return nil
 }
 
-// Exists returns true if the key exists in the session.
-func (s *Session) Exists(key string) bool {
-	if s.manager == nil || s.ctx == nil {
-		return false
+// closeOthers closes the live contexts of the session with the given token,
+// except the one this session was obtained from.
+func (s *Session) closeOthers(id string) {
+	if s.app == nil || (s.c != nil && s.c.id == "") {
+		return
 	}
-	return s.manager.Exists(s.ctx, key)
+	s.app.closeSessionContexts(id, s.c)
+}
+
+// Exists returns true if the key exists in the session.
+func (s *Session) Exists(key string) (v bool) {
+	s.read(func(ctx context.Context) { v = s.manager.Exists(ctx, key) })
+	return v
 }
 
 // Keys returns all keys in the session.
-func (s *Session) Keys() []string {
-	if s.manager == nil || s.ctx == nil {
-		return nil
-	}
-	return s.manager.Keys(s.ctx)
+func (s *Session) Keys() (v []string) {
+	s.read(func(ctx context.Context) { v = s.manager.Keys(ctx) })
+	return v
 }
 
 // ID returns the session token (cookie value).
 func (s *Session) ID() string {
-	if s.manager == nil || s.ctx == nil {
+	if s.manager == nil {
 		return ""
 	}
-	return s.manager.Token(s.ctx)
+	if sessionLoaded(s.manager, s.ctx) {
+		return s.manager.Token(s.ctx)
+	}
+	return s.token
 }
 
 // Pop retrieves a value and deletes it from the session (flash message pattern).
-func (s *Session) Pop(key string) any {
-	if s.manager == nil || s.ctx == nil {
+func (s *Session) Pop(key string) (v any) {
+	s.warn("pop '"+key+"'", s.write(func(ctx context.Context) error {
+		v = s.manager.Pop(ctx, key)
 		return nil
-	}
-	return s.manager.Pop(s.ctx, key)
+	}))
+	return v
 }
 
 // PopString retrieves a string value and deletes it from the session.
 func (s *Session) PopString(key string) string {
-	if s.manager == nil || s.ctx == nil {
-		return ""
-	}
-	return s.manager.PopString(s.ctx, key)
+	v, _ := s.Pop(key).(string)
+	return v
 }
 
 // PopInt retrieves an int value and deletes it from the session.
 func (s *Session) PopInt(key string) int {
-	if s.manager == nil || s.ctx == nil {
-		return 0
-	}
-	return s.manager.PopInt(s.ctx, key)
+	v, _ := s.Pop(key).(int)
+	return v
 }
 
 // PopBool retrieves a bool value and deletes it from the session.
 func (s *Session) PopBool(key string) bool {
-	if s.manager == nil || s.ctx == nil {
-		return false
-	}
-	return s.manager.PopBool(s.ctx, key)
+	v, _ := s.Pop(key).(bool)
+	return v
 }
 
 // GetFloat64 retrieves a float64 value from the session.
-func (s *Session) GetFloat64(key string) float64 {
-	if s.manager == nil || s.ctx == nil {
-		return 0
-	}
-	return s.manager.GetFloat(s.ctx, key)
+func (s *Session) GetFloat64(key string) (v float64) {
+	s.read(func(ctx context.Context) { v = s.manager.GetFloat(ctx, key) })
+	return v
 }
 
 // PopFloat64 retrieves a float64 value and deletes it from the session.
 func (s *Session) PopFloat64(key string) float64 {
-	if s.manager == nil || s.ctx == nil {
-		return 0
-	}
-	return s.manager.PopFloat(s.ctx, key)
+	v, _ := s.Pop(key).(float64)
+	return v
 }
 
 // GetTime retrieves a time.Time value from the session.
-func (s *Session) GetTime(key string) time.Time {
-	if s.manager == nil || s.ctx == nil {
-		return time.Time{}
-	}
-	return s.manager.GetTime(s.ctx, key)
+func (s *Session) GetTime(key string) (v time.Time) {
+	s.read(func(ctx context.Context) { v = s.manager.GetTime(ctx, key) })
+	return v
 }
 
 // PopTime retrieves a time.Time value and deletes it from the session.
 func (s *Session) PopTime(key string) time.Time {
-	if s.manager == nil || s.ctx == nil {
-		return time.Time{}
-	}
-	return s.manager.PopTime(s.ctx, key)
+	v, _ := s.Pop(key).(time.Time)
+	return v
 }
 
 // GetBytes retrieves a []byte value from the session.
-func (s *Session) GetBytes(key string) []byte {
-	if s.manager == nil || s.ctx == nil {
-		return nil
-	}
-	return s.manager.GetBytes(s.ctx, key)
+func (s *Session) GetBytes(key string) (v []byte) {
+	s.read(func(ctx context.Context) { v = s.manager.GetBytes(ctx, key) })
+	return v
 }
 
 // PopBytes retrieves a []byte value and deletes it from the session.
 func (s *Session) PopBytes(key string) []byte {
-	if s.manager == nil || s.ctx == nil {
-		return nil
+	v, _ := s.Pop(key).([]byte)
+	return v
+}
+
+// SessionGet returns the value stored under key by SessionSet. ok is false
+// if the key is missing or its value does not decode into T.
+//
+// Example:
+//
+//	cart, ok := via.SessionGet[Cart](c.Session(), "cart")
+func SessionGet[T any](s *Session, key string) (val T, ok bool) {
+	return decodeSessionValue[T](s.Get(key))
+}
+
+// SessionSet stores val under key as JSON, so any JSON serializable type
+// works without gob.Register.
+func SessionSet[T any](s *Session, key string, val T) error {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("session set '%s' failed: %w", key, err)
+	}
+	return s.set(key, b)
+}
+
+// SessionPop returns the value stored under key by SessionSet and deletes
+// it from the session.
+func SessionPop[T any](s *Session, key string) (val T, ok bool) {
+	return decodeSessionValue[T](s.Pop(key))
+}
+
+func decodeSessionValue[T any](raw any) (val T, ok bool) {
+	b, isBytes := raw.([]byte)
+	if !isBytes {
+		return val, false
+	}
+	if err := json.Unmarshal(b, &val); err != nil {
+		return val, false
 	}
-	return s.manager.PopBytes(s.ctx, key)
+	return val, true
 }
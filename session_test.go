@@ -0,0 +1,128 @@
+package via
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/ryanhamamura/via/h"
+	"github.com/ryanhamamura/via/internal/sessiontest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestMemoryStore_Conformance(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T, cleanup time.Duration) (scs.Store, func() int) {
+		// memstore keeps its entries private, so cleanup is not checked
+		return memstore.NewWithCleanupInterval(0), nil
+	})
+	require.IsType(t, &memstore.MemStore{}, NewMemorySessionManager().Store)
+}
+
+func TestBoltStore_Conformance(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T, cleanup time.Duration) (scs.Store, func() int) {
+		db, err := bbolt.Open(filepath.Join(t.TempDir(), "sessions.db"), 0600, nil)
+		require.NoError(t, err)
+		s, err := NewBoltStore(db, cleanup)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			s.StopCleanup()
+			db.Close()
+		})
+		stored := func() int {
+			n := 0
+			_ = db.View(func(tx *bbolt.Tx) error {
+				n = tx.Bucket(boltSessionBucket).Stats().KeyN
+				return nil
+			})
+			return n
+		}
+		return s, stored
+	})
+}
+
+func TestSession_DetachedWritesGoToStore(t *testing.T) {
+	v := New()
+	sm := v.sessionManager
+	_, token := newStoredSession(t, sm)
+
+	// e.g. an OnInterval routine: no request in flight
+	c := newContext("c", "/", v)
+	c.setSessionKey(token)
+	s := c.Session()
+	require.NoError(t, s.Err())
+	assert.Equal(t, "alice", s.GetString("user"))
+	s.Set("theme", "dark")
+	assert.Equal(t, token, s.ID())
+
+	ctx, err := sm.Load(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "dark", sm.GetString(ctx, "theme"))
+
+	assert.Equal(t, "dark", c.Session().PopString("theme"))
+	assert.False(t, c.Session().Exists("theme"), "pop should be committed")
+	assert.ErrorIs(t, c.Session().RenewToken(), ErrNoRequest)
+}
+
+func TestSession_ErrNoSession(t *testing.T) {
+	v := New()
+	c := newContext("c", "/", v)
+	s := c.Session()
+	assert.ErrorIs(t, s.Err(), ErrNoSession)
+	assert.ErrorIs(t, s.Clear(), ErrNoSession)
+	s.Set("k", "v") // logs instead of panicking
+	assert.Nil(t, s.Get("k"))
+
+	c.setSessionKey("expired-or-revoked")
+	assert.ErrorIs(t, c.Session().Err(), ErrNoSession)
+
+	v.sessionManager = nil
+	assert.NoError(t, c.Session().Err(), "sessions are a no-op when not configured")
+}
+
+func TestAction_CommitsSessionBeforeRedirect(t *testing.T) {
+	v := New()
+	var save *actionTrigger
+	redirectedEarly := false
+	v.Page("/", func(c *Context) {
+		save = c.Action(func() {
+			c.Session().Set("saved", "yes")
+			c.Redirect("/done")
+			redirectedEarly = len(c.patchChan) > 0
+		})
+		c.View(func() h.H { return h.Div() })
+	})
+	handler := v.sessionManager.LoadAndSave(v.mux)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	var c *Context
+	for _, rc := range v.contextRegistry {
+		c = rc
+	}
+	require.NotNil(t, c)
+	assert.Empty(t, c.sessionKey(), "page load did not start a session")
+
+	sigs, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/_action/"+save.id+"?datastar="+url.QueryEscape(string(sigs)), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	assert.False(t, redirectedEarly, "redirect should wait for the session commit")
+	assert.Equal(t, patch{patchTypeRedirect, "/done"}, <-c.patchChan)
+	token := c.sessionKey()
+	require.NotEmpty(t, token, "context should be bound to the new session")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), token)
+	b, found, err := v.sessionManager.Store.Find(token)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Contains(t, string(b), "yes")
+	assert.Nil(t, c.requestCtx(), "request context should not outlive the action")
+}
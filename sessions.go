@@ -0,0 +1,170 @@
+package via
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCloseSubject = "via.session.close"
+	// redirectGrace bounds how long a closing context waits for its
+	// redirect to reach the browser before it is disposed anyway.
+	redirectGrace = 2 * time.Second
+)
+
+type sessionCloseMsg struct {
+	Instance string `json:"instance"`
+	Session  string `json:"session"`
+}
+
+// sessionCloser closes the live contexts of sessions that ended on this or
+// any other instance sharing the configured PubSub.
+type sessionCloser struct {
+	app       *V
+	instance  string
+	startOnce sync.Once
+	sub       Subscription
+}
+
+func (v *V) sessionCloser() *sessionCloser {
+	v.sessionsOnce.Do(func() {
+		v.sessions = &sessionCloser{app: v, instance: genRandID()}
+	})
+	return v.sessions
+}
+
+func (s *sessionCloser) start() {
+	s.startOnce.Do(func() {
+		ps := s.app.pubsub
+		if ps == nil {
+			return
+		}
+		sub, err := ps.Subscribe(sessionCloseSubject, s.handle)
+		if err != nil {
+			s.app.logErr(nil, "session close subscribe failed: %v", err)
+			return
+		}
+		s.sub = sub
+	})
+}
+
+func (s *sessionCloser) close() {
+	if s.sub != nil {
+		s.sub.Unsubscribe()
+	}
+}
+
+func (s *sessionCloser) publish(id string) {
+	ps := s.app.pubsub
+	if ps == nil {
+		return
+	}
+	data, err := json.Marshal(sessionCloseMsg{Instance: s.instance, Session: id})
+	if err != nil {
+		return
+	}
+	if err := ps.Publish(sessionCloseSubject, data); err != nil {
+		s.app.logWarn(nil, "session close publish failed: %v", err)
+	}
+}
+
+func (s *sessionCloser) handle(data []byte) {
+	var msg sessionCloseMsg
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Instance == s.instance {
+		return
+	}
+	s.app.closeLocalSessionContexts(msg.Session, nil)
+}
+
+// ContextsForSession returns the live page contexts on this instance that
+// were opened by the session with the given token.
+func (v *V) ContextsForSession(id string) []*Context {
+	if id == "" {
+		return nil
+	}
+	return v.contextsMatching(BySession(id))
+}
+
+// RevokeSession ends the session with the given token, e.g. from an admin
+// page, and closes its live contexts on every instance sharing the
+// configured PubSub. See CloseSessionContexts for how contexts are closed.
+func (v *V) RevokeSession(id string) error {
+	if id == "" {
+		return fmt.Errorf("revoke session failed: empty session id")
+	}
+	if v.sessionManager != nil && v.sessionManager.Store != nil {
+		if err := v.sessionManager.Store.Delete(id); err != nil {
+			return fmt.Errorf("revoke session failed: %w", err)
+		}
+	}
+	v.CloseSessionContexts(id)
+	return nil
+}
+
+// CloseSessionContexts closes every live context of the session with the
+// given token on this and other instances, without touching the session
+// data. Connected browsers are redirected to Options.SessionClosedURL, or
+// reload their page if it is not set, before the context is disposed. The
+// contexts stop accepting actions right away.
+//
+// Session.Destroy and Session.RenewToken call it for the other tabs of the
+// session, so logging out in one tab logs out all of them.
+func (v *V) CloseSessionContexts(id string) int {
+	return v.closeSessionContexts(id, nil)
+}
+
+func (v *V) closeSessionContexts(id string, except *Context) int {
+	if id == "" {
+		return 0
+	}
+	n := v.closeLocalSessionContexts(id, except)
+	v.sessionCloser().publish(id)
+	return n
+}
+
+func (v *V) closeLocalSessionContexts(id string, except *Context) int {
+	n := 0
+	for _, c := range v.ContextsForSession(id) {
+		if except != nil && c == except.pageCtx() {
+			continue
+		}
+		v.closeCtx(c)
+		n++
+	}
+	if n > 0 {
+		v.logInfo(nil, "closed %d context(s) of ended session", n)
+	}
+	return n
+}
+
+// closeCtx unregisters c so it rejects further actions, redirects the
+// browser and disposes c once the redirect was sent.
+func (v *V) closeCtx(c *Context) {
+	v.unregisterCtx(c)
+	if !c.sseConnected.Load() {
+		v.cleanupCtx(c)
+		return
+	}
+	url := v.cfg.SessionClosedURL
+	if url == "" {
+		url = c.path()
+	}
+	c.closing.Store(true)
+	go func() {
+		select {
+		case c.patchChan <- patch{patchTypeRedirect, url}:
+		case <-c.ctxDisposedChan:
+			return
+		case <-time.After(redirectGrace):
+		}
+		// the SSE loop disposes c after sending the redirect; this is the
+		// fallback for browsers that went away in the meantime
+		select {
+		case <-c.ctxDisposedChan:
+		case <-time.After(redirectGrace):
+			v.cleanupCtx(c)
+		}
+	}()
+}
@@ -0,0 +1,126 @@
+package via
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStoredSession commits a session to sm and returns a request context
+// loaded with it, as LoadAndSave would.
+func newStoredSession(t *testing.T, sm *scs.SessionManager) (context.Context, string) {
+	t.Helper()
+	ctx, err := sm.Load(context.Background(), "")
+	require.NoError(t, err)
+	sm.Put(ctx, "user", "alice")
+	token, _, err := sm.Commit(ctx)
+	require.NoError(t, err)
+	ctx, err = sm.Load(context.Background(), token)
+	require.NoError(t, err)
+	return ctx, token
+}
+
+func TestContextsForSession(t *testing.T) {
+	v := New()
+	a := newBroadcastCtx(v, "a", "/", nil)
+	b := newBroadcastCtx(v, "b", "/", nil)
+	newBroadcastCtx(v, "c", "/", nil)
+	a.setSessionKey("sess-1")
+	b.setSessionKey("sess-1")
+
+	assert.ElementsMatch(t, []*Context{a, b}, v.ContextsForSession("sess-1"))
+	assert.Empty(t, v.ContextsForSession(""))
+}
+
+func TestSessionDestroy_ClosesOtherTabs(t *testing.T) {
+	v := New()
+	ctx, token := newStoredSession(t, v.sessionManager)
+
+	current := newBroadcastCtx(v, "current", "/", nil)
+	current.reqCtx = ctx
+	current.setSessionKey(token)
+	other := newBroadcastCtx(v, "other", "/orders/{id}", map[string]string{"id": "42"})
+	other.setSessionKey(token)
+	other.sseConnected.Store(true)
+	stranger := newBroadcastCtx(v, "stranger", "/", nil)
+	stranger.setSessionKey("someone-else")
+
+	require.NoError(t, current.Session().Destroy())
+
+	_, err := v.getCtx("other")
+	assert.Error(t, err, "other tab should no longer accept actions")
+	assert.True(t, other.closing.Load())
+	p := <-other.patchChan
+	assert.Equal(t, patch{patchTypeRedirect, "/orders/42"}, p)
+
+	_, err = v.getCtx("current")
+	assert.NoError(t, err, "the tab that logged out stays open to redirect itself")
+	_, err = v.getCtx("stranger")
+	assert.NoError(t, err)
+}
+
+func TestSessionRenewToken_RebindsCurrentAndClosesOthers(t *testing.T) {
+	v := New()
+	v.Config(Options{SessionClosedURL: "/login"})
+	ctx, token := newStoredSession(t, v.sessionManager)
+
+	current := newBroadcastCtx(v, "current", "/", nil)
+	current.reqCtx = ctx
+	current.setSessionKey(token)
+	other := newBroadcastCtx(v, "other", "/", nil)
+	other.setSessionKey(token)
+	other.sseConnected.Store(true)
+
+	require.NoError(t, current.Session().RenewToken())
+
+	assert.NotEqual(t, token, current.sessionKey())
+	assert.Equal(t, v.sessionManager.Token(ctx), current.sessionKey())
+	assert.Equal(t, patch{patchTypeRedirect, "/login"}, <-other.patchChan)
+	assert.Empty(t, v.ContextsForSession(token))
+}
+
+func TestSessionCloser_SubscribesOnConfig(t *testing.T) {
+	ps := newMockPubSub()
+	v := New()
+	v.Config(Options{PubSub: ps})
+	assert.Len(t, ps.subs[sessionCloseSubject], 1, "should subscribe before any context registers")
+
+	c := newBroadcastCtx(v, "s1", "/", nil)
+	c.setSessionKey("token")
+	v.registerCtx(c)
+	assert.Len(t, ps.subs[sessionCloseSubject], 1)
+}
+
+func TestRevokeSession_AcrossInstances(t *testing.T) {
+	ps := newMockPubSub()
+	sm := scs.New()
+	v1, v2 := New(), New()
+	v1.Config(Options{PubSub: ps, SessionManager: sm})
+	v2.Config(Options{PubSub: ps, SessionManager: sm})
+	_, token := newStoredSession(t, sm)
+
+	local := newBroadcastCtx(v1, "local", "/", nil)
+	local.setSessionKey(token)
+	v1.registerCtx(local)
+	remote := newBroadcastCtx(v2, "remote", "/", nil)
+	remote.setSessionKey(token)
+	v2.registerCtx(remote)
+
+	require.NoError(t, v1.RevokeSession(token))
+
+	_, found, err := sm.Store.Find(token)
+	require.NoError(t, err)
+	assert.False(t, found, "revoked session should be deleted from the store")
+	assert.Empty(t, v1.ContextsForSession(token))
+	assert.Empty(t, v2.ContextsForSession(token))
+	select {
+	case <-remote.Done():
+	default:
+		t.Fatal("remote context without SSE should be disposed right away")
+	}
+
+	assert.Error(t, v1.RevokeSession(""))
+}
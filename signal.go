@@ -18,6 +18,10 @@ type signal struct {
 	val     any
 	changed bool
 	err     error
+	// computed is the expression of a signal from Context.Computed, which
+	// the browser derives and the server only reads.
+	computed h.Expr
+	deps     []*signal
 }
 
 // ID returns the signal ID
@@ -40,7 +44,7 @@ func (s *signal) Err() error {
 //
 //	h.Input(h.Type("number"), mysignal.Bind())
 func (s *signal) Bind() h.H {
-	return h.Data("bind", s.id)
+	return h.DataBind(s.id)
 }
 
 // Text binds the signal value to an html span element as text.
@@ -49,7 +53,114 @@ func (s *signal) Bind() h.H {
 //
 //	h.Div(mysignal.Text())
 func (s *signal) Text() h.H {
-	return h.Span(h.Data("text", "$"+s.id))
+	return h.Span(h.DataText(s.Expr()))
+}
+
+// Expr references the signal in Datastar expressions of h's data-*
+// builders.
+//
+// Example:
+//
+//	h.Div(h.DataShow(h.Not(mysignal.Expr())), h.Text("Loading..."))
+func (s *signal) Expr() h.Expr {
+	return h.Signal(s.id)
+}
+
+// Show shows the element while the signal is truthy and all of cond hold,
+// without a round trip to the server. Conditions that hold for a falsy
+// signal, such as Eq(""), need h.DataShow.
+//
+// Example:
+//
+//	h.Div(loading.Show(), h.Text("Loading..."))
+//	h.Div(tab.Show(tab.Eq("settings")), settingsView())
+func (s *signal) Show(cond ...h.Expr) h.H {
+	return h.DataShow(s.truthyAnd(cond))
+}
+
+// ClassWhen adds class to the element while the signal is truthy and all of
+// cond hold.
+//
+// Example:
+//
+//	h.Li(h.Text(task.Title), done.ClassWhen("done"))
+//	h.A(h.Href("/"), h.Text("Home"), tab.ClassWhen("active", tab.Eq("home")))
+func (s *signal) ClassWhen(class string, cond ...h.Expr) h.H {
+	return h.DataClass(class, s.truthyAnd(cond))
+}
+
+func (s *signal) truthyAnd(cond []h.Expr) h.Expr {
+	return h.And(append([]h.Expr{s.Expr()}, cond...)...)
+}
+
+// AttrBind sets attribute name of the element to the signal value. A false
+// signal removes the attribute.
+//
+// Example:
+//
+//	h.Button(h.Text("Save"), saving.AttrBind("disabled"))
+func (s *signal) AttrBind(name string) h.H {
+	return h.DataAttr(name, s.Expr())
+}
+
+// Eq is true while the signal equals v. Signals reach the browser as
+// strings, so comparisons are loose: sig.Eq(1) holds for "1". v may be
+// another signal.
+func (s *signal) Eq(v any) h.Expr {
+	return s.compare("==", v)
+}
+
+// Ne is true while the signal does not equal v.
+func (s *signal) Ne(v any) h.Expr {
+	return s.compare("!=", v)
+}
+
+// Gt is true while the signal is greater than v.
+func (s *signal) Gt(v any) h.Expr {
+	return s.compare(">", v)
+}
+
+// Ge is true while the signal is greater than or equal to v.
+func (s *signal) Ge(v any) h.Expr {
+	return s.compare(">=", v)
+}
+
+// Lt is true while the signal is less than v.
+func (s *signal) Lt(v any) h.Expr {
+	return s.compare("<", v)
+}
+
+// Le is true while the signal is less than or equal to v.
+func (s *signal) Le(v any) h.Expr {
+	return s.compare("<=", v)
+}
+
+// Not is true while the signal is falsy.
+func (s *signal) Not() h.Expr {
+	return h.Not(s.Expr())
+}
+
+// Set assigns v to the signal in the browser, e.g. in h.DataOn. v may be
+// another signal.
+//
+// Example:
+//
+//	h.Button(h.Text("Settings"), h.DataOn("click", tab.Set("settings")))
+func (s *signal) Set(v any) h.Expr {
+	return s.compare("=", v)
+}
+
+// Toggle flips a boolean signal in the browser.
+func (s *signal) Toggle() h.Expr {
+	return s.Expr() + " = " + s.Not()
+}
+
+func (s *signal) compare(op string, v any) h.Expr {
+	operand := h.Lit(v)
+	if o, ok := v.(*signal); ok {
+		operand = o.Expr()
+	}
+	return s.Expr() + h.Expr(" "+op+" ") + operand
 }
 
 // SetValue updates the signal’s value and marks it for synchronization with the browser.
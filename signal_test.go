@@ -1,7 +1,8 @@
 package via
 
 import (
-	//	"net/http/httptest"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/ryanhamamura/via/h"
@@ -67,3 +68,99 @@ func TestSignalReturnAsStringComplexTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestSignalExpr(t *testing.T) {
+	var sig *signal
+	v := New()
+	v.Page("/", func(c *Context) {
+		sig = c.Signal(false)
+		c.View(func() h.H { return h.Div() })
+	})
+	assert.Equal(t, h.Expr("$"+sig.ID()), sig.Expr())
+
+	var b strings.Builder
+	assert.NoError(t, h.Div(h.DataShow(h.Not(sig.Expr())), sig.Bind()).Render(&b))
+	assert.Equal(t, `<div data-show="!($`+sig.ID()+`)" data-bind="`+sig.ID()+`"></div>`, b.String())
+}
+
+func TestSignalReactiveHelpers(t *testing.T) {
+	var tab, n, other *signal
+	v := New()
+	v.Page("/", func(c *Context) {
+		tab = c.Signal("home")
+		n = c.Signal(1)
+		other = c.Signal(2)
+		c.View(func() h.H { return h.Div() })
+	})
+	id, nid := "$"+tab.ID(), "$"+n.ID()
+
+	assert.Equal(t, h.Expr(id+` == "home"`), tab.Eq("home"))
+	assert.Equal(t, h.Expr(id+` != "it's"`), tab.Ne("it's"))
+	assert.Equal(t, h.Expr(nid+" > 3"), n.Gt(3))
+	assert.Equal(t, h.Expr(nid+" >= 3"), n.Ge(3))
+	assert.Equal(t, h.Expr(nid+" < $"+other.ID()), n.Lt(other))
+	assert.Equal(t, h.Expr(nid+" <= 1.5"), n.Le(1.5))
+	assert.Equal(t, h.Expr(id+` = "settings"`), tab.Set("settings"))
+	assert.Equal(t, h.Expr(id+" = !("+id+")"), tab.Toggle())
+
+	for _, tc := range []struct {
+		attr h.H
+		want string
+	}{
+		{tab.Show(), ` data-show="` + id + `"`},
+		{tab.Show(tab.Eq("home"), n.Gt(0)), ` data-show="(` + id + `) &amp;&amp; (` + id + ` == &#34;home&#34;) &amp;&amp; (` + nid + ` &gt; 0)"`},
+		{tab.ClassWhen("active"), ` data-class:active="` + id + `"`},
+		{tab.ClassWhen("active", tab.Eq("home")), ` data-class:active="(` + id + `) &amp;&amp; (` + id + ` == &#34;home&#34;)"`},
+		{h.DataShow(h.And(tab.Eq("home"), n.Gt(0))), ` data-show="(` + id + ` == &#34;home&#34;) &amp;&amp; (` + nid + ` &gt; 0)"`},
+		{n.AttrBind("data-count"), ` data-attr:data-count="` + nid + `"`},
+	} {
+		var b strings.Builder
+		assert.NoError(t, tc.attr.Render(&b))
+		assert.Equal(t, tc.want, b.String())
+	}
+}
+
+func TestComputed(t *testing.T) {
+	var price, qty, total *signal
+	var ctx *Context
+	v := New()
+	v.Page("/", func(c *Context) {
+		ctx = c
+		price = c.Signal(10)
+		qty = c.Signal(2)
+		total = c.Computed(price.Expr()+" * "+qty.Expr(), price, qty)
+		c.View(func() h.H { return h.Div(total.Text()) })
+	})
+
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	body := w.Body.String()
+	assert.NoError(t, total.Err())
+	assert.Contains(t, body, `data-computed:`+total.ID()+`="$`+price.ID()+` * $`+qty.ID()+`"`)
+	assert.Contains(t, body, `data-signals__ifmissing="{`)
+	assert.Contains(t, body, `&#34;`+price.ID()+`&#34;:&#34;10&#34;`)
+
+	sigs := ctx.prepareSignalsForPatch()
+	assert.Contains(t, sigs, price.ID())
+	assert.NotContains(t, sigs, total.ID(), "the browser owns computed values")
+
+	ctx.injectSignals(map[string]any{total.ID(): 20})
+	assert.Equal(t, 20, total.Int())
+}
+
+func TestComputed_UnknownDependency(t *testing.T) {
+	v := New()
+	var foreign, total *signal
+	v.Page("/a", func(c *Context) {
+		foreign = c.Signal(1)
+		c.View(func() h.H { return h.Div() })
+	})
+	v.Page("/b", func(c *Context) {
+		total = c.Computed(foreign.Expr()+" + 1", foreign)
+		c.View(func() h.H { return h.Div() })
+	})
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/b", nil))
+	assert.Error(t, total.Err())
+	assert.NotContains(t, w.Body.String(), "data-computed")
+}
@@ -0,0 +1,141 @@
+package via
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const defaultStoreInterval = 50 * time.Millisecond
+
+// Store holds shared state of type T that many contexts can depend on.
+// Contexts subscribe to a slice of the state with Use and are re-synced only
+// when the value of that slice changes. Updates are batched: contexts are
+// checked at most once per tick, no matter how many updates happened.
+//
+// Example:
+//
+//	var bookmarks = via.NewStore([]Bookmark{})
+//
+//	v.Page("/", func(c *via.Context) {
+//		count := via.Use(c, bookmarks, func(b []Bookmark) int { return len(b) })
+//		c.View(func() h.H {
+//			return h.P(h.Textf("%d bookmarks", count()))
+//		})
+//	})
+type Store[T any] struct {
+	mu       sync.RWMutex
+	val      T
+	interval time.Duration
+	pending  bool
+	subsMu   sync.Mutex
+	subs     map[*storeSub[T]]struct{}
+}
+
+type storeSub[T any] struct {
+	c     *Context
+	last  []byte
+	check func(val T) []byte
+}
+
+// NewStore creates a store holding initial.
+func NewStore[T any](initial T) *Store[T] {
+	return &Store[T]{
+		val:      initial,
+		interval: defaultStoreInterval,
+		subs:     make(map[*storeSub[T]]struct{}),
+	}
+}
+
+// Get returns a copy of the current state. Slices, maps and pointers
+// inside T are shared with the store and must not be mutated.
+func (s *Store[T]) Get() T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.val
+}
+
+// Update mutates the state under a write lock and schedules dependent
+// contexts to be checked on the next tick. fn should be fast.
+func (s *Store[T]) Update(fn func(*T)) {
+	s.mu.Lock()
+	fn(&s.val)
+	schedule := !s.pending
+	s.pending = true
+	s.mu.Unlock()
+	if schedule {
+		time.AfterFunc(s.interval, s.flush)
+	}
+}
+
+// flush syncs every context whose selected value changed since it was last
+// checked. Each context syncs at most once per flush.
+func (s *Store[T]) flush() {
+	s.mu.Lock()
+	s.pending = false
+	val := s.val
+	s.mu.Unlock()
+
+	s.subsMu.Lock()
+	var changed []*Context
+	seen := make(map[*Context]bool)
+	for sub := range s.subs {
+		snap := sub.check(val)
+		if bytes.Equal(snap, sub.last) && snap != nil {
+			continue
+		}
+		sub.last = snap
+		p := sub.c.pageCtx()
+		if !seen[p] && p.sseConnected.Load() {
+			seen[p] = true
+			changed = append(changed, sub.c)
+		}
+	}
+	s.subsMu.Unlock()
+
+	for _, c := range changed {
+		go c.app.runOnContext(c, func(c *Context) { c.Sync() })
+	}
+}
+
+func (s *Store[T]) subscribe(sub *storeSub[T]) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	s.subs[sub] = struct{}{}
+}
+
+func (s *Store[T]) unsubscribe(sub *storeSub[T]) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	delete(s.subs, sub)
+}
+
+// Use records that c depends on the slice of store picked by selector and
+// returns a getter for the current selected value, to be called from the
+// view. c is re-synced whenever the selected value changes and stops
+// tracking the store when disposed.
+//
+// Selected values are compared by their JSON encoding, so unexported
+// struct fields do not count as changes. A selector that cannot be
+// encoded re-syncs on every update.
+func Use[T, S any](c *Context, store *Store[T], selector func(T) S) func() S {
+	get := func() S { return selector(store.Get()) }
+	if c.id == "" {
+		return get
+	}
+	sub := &storeSub[T]{
+		c: c,
+		check: func(val T) []byte {
+			b, err := json.Marshal(selector(val))
+			if err != nil {
+				return nil
+			}
+			return b
+		},
+	}
+	sub.last = sub.check(store.Get())
+	store.subscribe(sub)
+	c.OnDispose(func() { store.unsubscribe(sub) })
+	return get
+}
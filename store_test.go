@@ -0,0 +1,84 @@
+package via
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storeState struct {
+	Count int
+	Names []string
+}
+
+func drainElementPatches(c *Context) int {
+	n := 0
+	for {
+		select {
+		case p := <-c.patchChan:
+			if p.typ == patchTypeElements {
+				n++
+			}
+		default:
+			return n
+		}
+	}
+}
+
+func TestStore_GetUpdate(t *testing.T) {
+	s := NewStore(storeState{Count: 1})
+	s.Update(func(st *storeState) { st.Count++ })
+	assert.Equal(t, 2, s.Get().Count)
+}
+
+func TestStore_SyncsOnlyDependentContexts(t *testing.T) {
+	v := New()
+	s := NewStore(storeState{})
+	s.interval = 5 * time.Millisecond
+
+	countCtx := newBroadcastCtx(v, "count", "/", nil)
+	countCtx.sseConnected.Store(true)
+	namesCtx := newBroadcastCtx(v, "names", "/", nil)
+	namesCtx.sseConnected.Store(true)
+
+	count := Use(countCtx, s, func(st storeState) int { return st.Count })
+	Use(namesCtx, s, func(st storeState) []string { return st.Names })
+
+	s.Update(func(st *storeState) { st.Count++ })
+	s.Update(func(st *storeState) { st.Count++ })
+
+	require.Eventually(t, func() bool { return len(countCtx.patchChan) > 0 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, drainElementPatches(countCtx), "batched updates should sync once")
+	assert.Equal(t, 0, drainElementPatches(namesCtx), "unrelated context should not sync")
+	assert.Equal(t, 2, count())
+
+	// in-place mutation of a selected slice is still detected
+	s.Update(func(st *storeState) { st.Names = append(st.Names, "a") })
+	require.Eventually(t, func() bool { return len(namesCtx.patchChan) > 0 }, time.Second, time.Millisecond)
+	drainElementPatches(namesCtx)
+	s.Update(func(st *storeState) { st.Names[0] = "b" })
+	require.Eventually(t, func() bool { return len(namesCtx.patchChan) > 0 }, time.Second, time.Millisecond)
+}
+
+func TestStore_UnsubscribesOnDispose(t *testing.T) {
+	v := New()
+	s := NewStore(storeState{})
+	c := newBroadcastCtx(v, "disposed", "/", nil)
+	Use(c, s, func(st storeState) int { return st.Count })
+	assert.Len(t, s.subs, 1)
+
+	v.cleanupCtx(c)
+	assert.Empty(t, s.subs)
+}
+
+func TestStore_NoOpDuringPanicCheck(t *testing.T) {
+	v := New()
+	s := NewStore(storeState{Count: 3})
+	c := newContext("", "/", v)
+	get := Use(c, s, func(st storeState) int { return st.Count })
+	assert.Equal(t, 3, get())
+	assert.Empty(t, s.subs)
+}
@@ -0,0 +1,78 @@
+package via
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ryanhamamura/via/h"
+)
+
+// suspense is a part of a view loaded in the background.
+type suspense struct {
+	id       string
+	fallback h.H
+	mu       sync.Mutex
+	content  h.H
+	done     bool
+}
+
+// Suspense renders fallback in place of a slow part of the view, runs load
+// in the background and patches its result in over SSE once it returns, so
+// the page is sent without waiting for it. Place the returned func in the
+// view like a component; it renders the result in later syncs.
+//
+// load runs once per page, with a context cancelled when the page is
+// disposed. The result is wrapped in a div with a generated id.
+//
+// Example:
+//
+//	report := c.Suspense(h.P(h.Text("Loading report…")), func(ctx context.Context) h.H {
+//		rows, _ := db.QueryContext(ctx, reportQuery)
+//		return reportTable(rows)
+//	})
+//	c.View(func() h.H { return h.Main(h.H1(h.Text("Report")), report()) })
+func (c *Context) Suspense(fallback h.H, load func(ctx context.Context) h.H) func() h.H {
+	s := &suspense{id: "suspense-" + genRandID(), fallback: fallback}
+	if c.pageCtx().id == "" {
+		return s.view
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.OnDispose(cancel)
+	go func() {
+		defer cancel()
+		content := c.loadSuspense(ctx, load)
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		s.content, s.done = content, true
+		s.mu.Unlock()
+		// pages that are not connected yet render the content when the SSE
+		// stream starts and syncs
+		if c.pageCtx().sseConnected.Load() {
+			c.app.runOnContext(c, func(c *Context) { c.SyncElements(s.view()) })
+		}
+	}()
+	return s.view
+}
+
+// loadSuspense runs load, turning a panic into an empty result.
+func (c *Context) loadSuspense(ctx context.Context, load func(ctx context.Context) h.H) (content h.H) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.app.logErr(c, "suspense load failed: %v", r)
+			content = nil
+		}
+	}()
+	return load(ctx)
+}
+
+func (s *suspense) view() h.H {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return h.Div(h.ID(s.id), s.content)
+	}
+	return h.Div(h.ID(s.id), s.fallback)
+}
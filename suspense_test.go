@@ -0,0 +1,146 @@
+package via
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectSSE opens the SSE stream of c and returns a scanner over it.
+func connectSSE(t *testing.T, srv *httptest.Server, c *Context) *bufio.Scanner {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	sigs, _ := json.Marshal(map[string]string{"via-ctx": c.id, "via-csrf": c.csrfToken})
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/_sse?datastar="+url.QueryEscape(string(sigs)), nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return bufio.NewScanner(resp.Body)
+}
+
+func scanFor(t *testing.T, sc *bufio.Scanner, s string) {
+	t.Helper()
+	for sc.Scan() {
+		if strings.Contains(sc.Text(), s) {
+			return
+		}
+	}
+	t.Fatalf("%q not received", s)
+}
+
+func TestSuspense_PatchesWhenLoaded(t *testing.T) {
+	v := New()
+	release := make(chan struct{})
+	var ctx *Context
+	var loads atomic.Int32
+	v.Page("/", func(c *Context) {
+		ctx = c
+		report := c.Suspense(h.P(h.Text("Loading")), func(context.Context) h.H {
+			loads.Add(1)
+			<-release
+			return h.Table(h.Tr(h.Td(h.Text("row 1"))))
+		})
+		c.View(func() h.H { return h.Main(report()) })
+	})
+	srv := httptest.NewServer(v.mux)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Regexp(t, `<main><div id="suspense-[0-9a-f]+"><p>Loading</p></div></main>`, string(body))
+
+	sc := connectSSE(t, srv, ctx)
+	scanFor(t, sc, "Loading")
+	close(release)
+	scanFor(t, sc, "row 1")
+	assert.Equal(t, int32(1), loads.Load(), "the registration check does not load")
+
+	var b strings.Builder
+	require.NoError(t, ctx.view().Render(&b))
+	assert.Contains(t, b.String(), "row 1", "later syncs render the content")
+	assert.NotContains(t, b.String(), "Loading")
+}
+
+func TestSuspense_LoadedBeforeConnect(t *testing.T) {
+	v := New()
+	loaded := make(chan struct{})
+	var ctx *Context
+	v.Page("/", func(c *Context) {
+		ctx = c
+		report := c.Suspense(h.P(h.Text("Loading")), func(context.Context) h.H {
+			defer close(loaded)
+			return h.P(h.Text("ready"))
+		})
+		c.View(func() h.H { return h.Main(report()) })
+	})
+	srv := httptest.NewServer(v.mux)
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	<-loaded
+	scanFor(t, connectSSE(t, srv, ctx), "ready")
+}
+
+func TestSuspense_CancelledOnDispose(t *testing.T) {
+	v := New()
+	cancelled := make(chan struct{})
+	var ctx *Context
+	v.Page("/", func(c *Context) {
+		ctx = c
+		report := c.Suspense(h.P(h.Text("Loading")), func(lctx context.Context) h.H {
+			<-lctx.Done()
+			close(cancelled)
+			return h.P(h.Text("too late"))
+		})
+		c.View(func() h.H { return h.Main(report()) })
+	})
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	v.cleanupCtx(ctx)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("loader not cancelled")
+	}
+}
+
+func TestSuspense_LoaderPanic(t *testing.T) {
+	v := New()
+	done := make(chan struct{})
+	var ctx *Context
+	var report func() h.H
+	v.Page("/", func(c *Context) {
+		ctx = c
+		report = c.Suspense(h.P(h.Text("Loading")), func(context.Context) h.H {
+			defer close(done)
+			panic("db down")
+		})
+		c.View(func() h.H { return h.Main(report()) })
+	})
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	<-done
+	assert.Eventually(t, func() bool {
+		var b strings.Builder
+		_ = ctx.view().Render(&b)
+		return !strings.Contains(b.String(), "Loading")
+	}, time.Second, 10*time.Millisecond, "the fallback is replaced by an empty result")
+}
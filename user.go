@@ -0,0 +1,89 @@
+package via
+
+import (
+	"context"
+	"slices"
+)
+
+const userSessionKey = "via.user"
+
+// User is the signed-in user of a session. It is stored in the session by
+// Session.SetUser, usually through the via/auth package.
+type User struct {
+	ID    string            `json:"id"`
+	Name  string            `json:"name,omitempty"`
+	Email string            `json:"email,omitempty"`
+	Roles []string          `json:"roles,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// HasRole reports whether the user has the given role.
+func (u *User) HasRole(role string) bool {
+	return u != nil && slices.Contains(u.Roles, role)
+}
+
+// User returns the signed-in user of the session, or nil.
+func (s *Session) User() *User {
+	u, ok := SessionGet[User](s, userSessionKey)
+	if !ok {
+		return nil
+	}
+	return &u
+}
+
+// SetUser signs u in to the session, or signs the current user out if u is
+// nil. Call RenewToken before signing a user in to prevent session fixation.
+func (s *Session) SetUser(u *User) error {
+	var err error
+	if u == nil {
+		err = s.write(func(ctx context.Context) error {
+			s.manager.Remove(ctx, userSessionKey)
+			return nil
+		})
+	} else {
+		err = SessionSet(s, userSessionKey, *u)
+	}
+	if err == nil && s.c != nil {
+		s.c.cacheUser(u)
+	}
+	return err
+}
+
+// User returns the signed-in user of the page's session, or nil. It can be
+// called from views and actions alike; the user is read from the session
+// once per page and kept up to date by Session.SetUser and by every action
+// request, so it matches the user WithPermission checks. While Page checks
+// the init function at registration, User returns an empty user so views of
+// guarded pages may assume one.
+//
+// Example:
+//
+//	c.View(func() h.H {
+//		if u := c.User(); u != nil {
+//			return h.P(h.Textf("Hello, %s", u.Name))
+//		}
+//		return h.A(h.Href("/login"), h.Text("Sign in"))
+//	})
+func (c *Context) User() *User {
+	if c.id == "" {
+		return &User{}
+	}
+	p := c.pageCtx()
+	p.mu.RLock()
+	u, loaded := p.user, p.userLoaded
+	p.mu.RUnlock()
+	if loaded {
+		return u
+	}
+	u = c.Session().User()
+	c.cacheUser(u)
+	return u
+}
+
+func (c *Context) cacheUser(u *User) {
+	p := c.pageCtx()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.user = u
+	p.userLoaded = true
+}
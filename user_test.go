@@ -0,0 +1,35 @@
+package via
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_SetAndCache(t *testing.T) {
+	v := New()
+	ctx, token := newStoredSession(t, v.sessionManager)
+	c := newContext("c", "/", v)
+	c.reqCtx = ctx
+	c.setSessionKey(token)
+
+	assert.Nil(t, c.User())
+	require.NoError(t, c.Session().SetUser(&User{ID: "u1", Name: "Alice", Roles: []string{"admin"}}))
+	assert.Equal(t, "Alice", c.User().Name)
+	assert.True(t, c.User().HasRole("admin"))
+	assert.False(t, c.User().HasRole("owner"))
+
+	// another page of the same session reads the user from the store
+	_, _, err := v.sessionManager.Commit(ctx)
+	require.NoError(t, err)
+	other := newContext("other", "/", v)
+	other.setSessionKey(token)
+	require.NotNil(t, other.User())
+	assert.Equal(t, "u1", other.User().ID)
+
+	require.NoError(t, c.Session().SetUser(nil))
+	assert.Nil(t, c.User())
+	var anonymous *User
+	assert.False(t, anonymous.HasRole("admin"))
+}
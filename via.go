@@ -9,7 +9,6 @@ package via
 import (
 	"context"
 	"crypto/rand"
-	"crypto/subtle"
 	_ "embed"
 	"encoding/hex"
 	"encoding/json"
@@ -54,6 +53,12 @@ type V struct {
 	datastarContent      []byte
 	datastarOnce         sync.Once
 	reaperStop           chan struct{}
+	presence             *presenceTracker
+	presenceOnce         sync.Once
+	sessions             *sessionCloser
+	sessionsOnce         sync.Once
+	limiters             LimiterStore
+	limitersOnce         sync.Once
 }
 
 func (v *V) logEvent(evt *zerolog.Event, c *Context) *zerolog.Event {
@@ -131,12 +136,92 @@ func (v *V) Config(cfg Options) {
 	if cfg.PubSub != nil {
 		v.pubsub = cfg.PubSub
 	}
+	if ps, ok := v.pubsub.(pubsubLogger); ok {
+		ps.SetLogger(v.logger)
+	}
+	if cfg.PubSub != nil {
+		// subscribe here rather than on the first session context, so that
+		// registering contexts never waits on the network
+		v.sessionCloser().start()
+	}
 	if cfg.ContextTTL != 0 {
 		v.cfg.ContextTTL = cfg.ContextTTL
 	}
-	if cfg.ActionRateLimit.Rate != 0 || cfg.ActionRateLimit.Burst != 0 {
+	if cfg.ActionRateLimit.Rate != 0 || cfg.ActionRateLimit.Burst != 0 || cfg.ActionRateLimit.Key != nil {
 		v.actionRateLimit = cfg.ActionRateLimit
 	}
+	if cfg.PresenceMeta != nil {
+		v.cfg.PresenceMeta = cfg.PresenceMeta
+	}
+	if cfg.SessionClosedURL != "" {
+		v.cfg.SessionClosedURL = cfg.SessionClosedURL
+	}
+	if cfg.Policy != nil {
+		v.cfg.Policy = cfg.Policy
+	}
+	if cfg.Audit != nil {
+		v.cfg.Audit = cfg.Audit
+	}
+	if cfg.CheckOrigin {
+		v.cfg.CheckOrigin = true
+	}
+	if len(cfg.TrustedOrigins) > 0 {
+		v.cfg.TrustedOrigins = cfg.TrustedOrigins
+	}
+	if cfg.ContentSecurityPolicy != "" {
+		v.cfg.ContentSecurityPolicy = cfg.ContentSecurityPolicy
+	}
+	if len(cfg.TrustedProxies) > 0 {
+		v.cfg.TrustedProxies = cfg.TrustedProxies
+	}
+	if cfg.PageRateLimit.Rate != 0 {
+		v.cfg.PageRateLimit = cfg.PageRateLimit
+	}
+	if cfg.SSERateLimit.Rate != 0 {
+		v.cfg.SSERateLimit = cfg.SSERateLimit
+	}
+	if cfg.MaxContextsPerSession != 0 {
+		v.cfg.MaxContextsPerSession = cfg.MaxContextsPerSession
+	}
+	if cfg.LimiterStore != nil {
+		v.cfg.LimiterStore = cfg.LimiterStore
+	}
+	if cfg.Render != RenderAsIs {
+		v.cfg.Render = cfg.Render
+	}
+}
+
+// flush is a node that sends what was rendered before it to the browser
+// when rendered to an http.ResponseWriter. It renders nothing.
+type flush struct{}
+
+func (flush) Render(w io.Writer) error {
+	if rw, ok := w.(http.ResponseWriter); ok {
+		_ = http.NewResponseController(rw).Flush()
+	}
+	return nil
+}
+
+// lazyView renders the view of c when the document is rendered, so a slow
+// view function runs after the head has been flushed.
+type lazyView struct {
+	c *Context
+}
+
+func (l lazyView) Render(w io.Writer) error {
+	return l.c.view().Render(w)
+}
+
+// render writes n to w in the configured RenderMode. RenderAsIs streams
+// to w; the other modes format the whole document first.
+func (v *V) render(w io.Writer, n h.H) error {
+	switch v.cfg.Render {
+	case RenderPretty:
+		return h.Pretty(w, n)
+	case RenderMinify:
+		return h.Minify(w, n)
+	}
+	return n.Render(w)
 }
 
 // AppendToHead appends the given h.H nodes to the head of the base HTML document.
@@ -169,7 +254,9 @@ func (v *V) AppendToFoot(elements ...h.H) {
 //			return h.H1(h.Text("Hello, Via!"))
 //		})
 //	})
-func (v *V) Page(route string, initContextFn func(c *Context)) {
+//
+// Guards run in order before the page is created; see Guard.
+func (v *V) Page(route string, initContextFn func(c *Context), guards ...Guard) {
 	v.ensureDatastarHandler()
 	// check for panics
 	func() {
@@ -196,39 +283,71 @@ func (v *V) Page(route string, initContextFn func(c *Context)) {
 			strings.Contains(r.URL.Path, "js.map") {
 			return
 		}
+		if v.cfg.PageRateLimit.Rate > 0 && !v.allowRequest("page", v.cfg.PageRateLimit, r) {
+			v.logWarn(nil, "GET %s rate limited", r.URL.Path)
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		for _, guard := range guards {
+			if !guard(w, r) {
+				v.logDebug(nil, "GET %s stopped by guard", r.URL.Path)
+				return
+			}
+		}
+		if max := v.cfg.MaxContextsPerSession; max > 0 {
+			if token := v.sessionToken(r.Context()); token != "" && len(v.ContextsForSession(token)) >= max {
+				v.logWarn(nil, "GET %s rejected: session has %d live pages", r.URL.Path, max)
+				http.Error(w, "too many open pages", http.StatusTooManyRequests)
+				return
+			}
+		}
 		id := fmt.Sprintf("%s_/%s", route, genRandID())
 		c := newContext(id, route, v)
-		c.reqCtx = r.Context()
+		c.setRequestCtx(r.Context())
+		defer c.setRequestCtx(nil)
+		c.setSessionKey(v.sessionToken(r.Context()))
 		routeParams := extractParams(route, r.URL.Path)
 		c.injectRouteParams(routeParams)
 		initContextFn(c)
+		v.commitSession(c, r.Context())
 		v.registerCtx(c)
 		if v.cfg.DevMode {
 			v.devModePersist(c)
 		}
-		headElements := []h.H{h.Script(h.Type("module"), h.Src(v.datastarPath))}
-		headElements = append(headElements, v.documentHeadIncludes...)
+		headElements := []h.H{h.Script(h.Type("module"), h.Attr("nonce", c.nonce), h.Src(v.datastarPath))}
+		for _, el := range v.documentHeadIncludes {
+			headElements = append(headElements, v.withNonce(c, el))
+		}
 		headElements = append(headElements,
 			h.Meta(h.Data("signals", fmt.Sprintf("{'via-ctx':'%s','via-csrf':'%s'}", id, c.csrfToken))),
 			h.Meta(h.Data("init", "@get('/_sse')")),
-			h.Meta(h.Data("init", fmt.Sprintf(`window.addEventListener('beforeunload', (evt) => {
-			navigator.sendBeacon('/_session/close', '%s');});`, c.id))),
+			closeBeaconScript(c),
 		)
+		headElements = append(headElements, c.computedHead()...)
 
-		bodyElements := []h.H{c.view()}
-		bodyElements = append(bodyElements, v.documentFootIncludes...)
+		// send the head, and with it the Datastar script, before rendering
+		// the view
+		bodyElements := []h.H{flush{}, lazyView{c}}
+		for _, el := range v.documentFootIncludes {
+			bodyElements = append(bodyElements, v.withNonce(c, el))
+		}
 		if v.cfg.DevMode {
-			bodyElements = append(bodyElements, h.Script(h.Type("module"),
+			bodyElements = append(bodyElements, h.Script(h.Type("module"), h.Attr("nonce", c.nonce),
 				h.Src("https://cdn.jsdelivr.net/gh/dataSPA/dataSPA-inspector@latest/dataspa-inspector.bundled.js")))
 			bodyElements = append(bodyElements, h.Raw("<dataspa-inspector/>"))
 		}
+		if csp := v.contentSecurityPolicy(c); csp != "" {
+			w.Header().Set("Content-Security-Policy", csp)
+		}
 		view := h.HTML5(h.HTML5Props{
 			Title:     v.cfg.DocumentTitle,
 			Head:      headElements,
 			Body:      bodyElements,
 			HTMLAttrs: []h.H{},
 		})
-		_ = view.Render(w)
+		if err := v.render(w, view); err != nil {
+			v.logErr(c, "page render failed: %v", err)
+		}
 	}))
 }
 
@@ -324,13 +443,9 @@ func (v *V) reapOrphanedContexts(ttl time.Duration) {
 // Start starts the Via HTTP server and blocks until a SIGINT or SIGTERM
 // signal is received, then performs a graceful shutdown.
 func (v *V) Start() {
-	handler := http.Handler(v.mux)
-	if v.sessionManager != nil {
-		handler = v.sessionManager.LoadAndSave(v.mux)
-	}
 	v.server = &http.Server{
 		Addr:    v.cfg.ServerAddress,
-		Handler: handler,
+		Handler: v.Handler(),
 	}
 
 	v.startReaper()
@@ -370,6 +485,12 @@ func (v *V) shutdown() {
 	}
 	v.logInfo(nil, "draining all contexts")
 	v.drainAllContexts()
+	if v.presence != nil {
+		v.presence.close()
+	}
+	if v.sessions != nil {
+		v.sessions.close()
+	}
 
 	if v.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -413,6 +534,16 @@ func (v *V) HTTPServeMux() *http.ServeMux {
 	return v.mux
 }
 
+// Handler returns the handler Start serves: the routes of the app wrapped
+// in the session middleware. Use it to serve the app from a server of your
+// own or in tests.
+func (v *V) Handler() http.Handler {
+	if v.sessionManager == nil {
+		return v.mux
+	}
+	return v.sessionManager.LoadAndSave(v.mux)
+}
+
 // Static serves files from a filesystem directory at the given URL prefix.
 //
 // Example:
@@ -462,6 +593,13 @@ func (v *V) ensureDatastarHandler() {
 	})
 }
 
+// devModeCtx is a context persisted in DevMode so that open pages survive a
+// restart of the app.
+type devModeCtx struct {
+	Route string `json:"route"`
+	CSRF  string `json:"csrf"`
+}
+
 func (v *V) devModePersist(c *Context) {
 	p := filepath.Join(".via", "devmode", "ctx.json")
 	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
@@ -470,7 +608,7 @@ func (v *V) devModePersist(c *Context) {
 
 	// load persisted list from file, or empty list if file not found
 	file, err := os.Open(p)
-	ctxRegMap := make(map[string]string)
+	ctxRegMap := make(map[string]devModeCtx)
 	if err == nil {
 		json.NewDecoder(file).Decode(&ctxRegMap)
 	}
@@ -478,7 +616,7 @@ func (v *V) devModePersist(c *Context) {
 
 	// add ctx to persisted list
 	if _, ok := ctxRegMap[c.id]; !ok {
-		ctxRegMap[c.id] = c.route
+		ctxRegMap[c.id] = devModeCtx{Route: c.route, CSRF: c.csrfToken}
 	}
 
 	// write persisted list to file
@@ -501,7 +639,7 @@ func (v *V) devModeRemovePersisted(c *Context) {
 
 	// load persisted list from file, or empty list if file not found
 	file, err := os.Open(p)
-	ctxRegMap := make(map[string]string)
+	ctxRegMap := make(map[string]devModeCtx)
 	if err == nil {
 		json.NewDecoder(file).Decode(&ctxRegMap)
 	}
@@ -535,19 +673,22 @@ func (v *V) devModeRestore(cID string) {
 		return
 	}
 	defer file.Close()
-	var ctxRegMap map[string]string
+	var ctxRegMap map[string]devModeCtx
 	if err := json.NewDecoder(file).Decode(&ctxRegMap); err != nil {
 		v.logWarn(nil, "devmode could not restore ctx from file: %v", err)
 		return
 	}
-	for ctxID, pageRoute := range ctxRegMap {
+	for ctxID, persisted := range ctxRegMap {
 		if ctxID == cID {
+			pageRoute := persisted.Route
 			pageInitFn, ok := v.devModePageInitFnMap[pageRoute]
 			if !ok {
 				v.logWarn(nil, "devmode could not restore ctx from file: page init fn for route '%s' not found", pageRoute)
 				continue
 			}
 			c := newContext(ctxID, pageRoute, v)
+			// keep the token of the page still open in the browser
+			c.csrfToken = persisted.CSRF
 			pageInitFn(c)
 			v.registerCtx(c)
 			v.logDebug(c, "devmode restored ctx")
@@ -590,9 +731,20 @@ func New() *V {
 	}
 
 	v.mux.HandleFunc("GET /_sse", func(w http.ResponseWriter, r *http.Request) {
+		if err := v.checkOrigin(r); err != nil {
+			v.logWarn(nil, "sse stream rejected: %v", err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if v.cfg.SSERateLimit.Rate > 0 && !v.allowRequest("sse", v.cfg.SSERateLimit, r) {
+			v.logWarn(nil, "sse stream rate limited")
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
 		var sigs map[string]any
 		_ = datastar.ReadSignals(r, &sigs)
 		cID, _ := sigs["via-ctx"].(string)
+		csrfToken, _ := sigs["via-csrf"].(string)
 
 		if v.cfg.DevMode {
 			if _, err := v.getCtx(cID); err != nil {
@@ -604,8 +756,11 @@ func New() *V {
 			v.logErr(nil, "sse stream failed to start: %v", err)
 			return
 		}
-		c.reqCtx = r.Context()
-
+		if err := v.verifyCtxRequest(c, r, csrfToken); err != nil {
+			v.logWarn(c, "sse stream rejected: %v", err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
 		sse := datastar.NewSSE(w, r, datastar.WithCompression(datastar.WithBrotli(datastar.WithBrotliLevel(5))))
 
 		// use last-event-id to tell if request is a sse reconnect
@@ -613,6 +768,7 @@ func New() *V {
 
 		c.sseConnected.Store(true)
 		v.logDebug(c, "SSE connection established")
+		scriptNonce := datastar.WithExecuteScriptAttributeKVs("nonce", c.nonce)
 
 		go func() {
 			c.Sync()
@@ -643,22 +799,27 @@ func New() *V {
 						}
 					}
 				case patchTypeScript:
-					if err := sse.ExecuteScript(patch.content, datastar.WithExecuteScriptAutoRemove(true)); err != nil {
+					if err := sse.ExecuteScript(patch.content, datastar.WithExecuteScriptAutoRemove(true), scriptNonce); err != nil {
 						if sse.Context().Err() == nil {
 							v.logErr(c, "ExecuteScript failed: %v", err)
 						}
 					}
 				case patchTypeRedirect:
-					if err := sse.Redirect(patch.content); err != nil {
+					if err := sse.Redirect(patch.content, scriptNonce); err != nil {
 						if sse.Context().Err() == nil {
 							v.logErr(c, "Redirect failed: %v", err)
 						}
 					}
+					if c.closing.Load() {
+						v.logDebug(c, "session ended, closing SSE")
+						v.cleanupCtx(c)
+						return
+					}
 				case patchTypeReplaceURL:
 					parsedURL, err := url.Parse(patch.content)
 					if err != nil {
 						v.logErr(c, "ReplaceURL failed to parse URL: %v", err)
-					} else if err := sse.ReplaceURL(*parsedURL); err != nil {
+					} else if err := sse.ReplaceURL(*parsedURL, scriptNonce); err != nil {
 						if sse.Context().Err() == nil {
 							v.logErr(c, "ReplaceURL failed: %v", err)
 						}
@@ -670,6 +831,11 @@ func New() *V {
 
 	v.mux.HandleFunc("GET /_action/{id}", func(w http.ResponseWriter, r *http.Request) {
 		actionID := r.PathValue("id")
+		if err := v.checkOrigin(r); err != nil {
+			v.logWarn(nil, "action '%s' rejected: %v", actionID, err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
 		var sigs map[string]any
 		_ = datastar.ReadSignals(r, &sigs)
 		cID, _ := sigs["via-ctx"].(string)
@@ -678,23 +844,37 @@ func New() *V {
 			v.logErr(nil, "action '%s' failed: %v", actionID, err)
 			return
 		}
+		if c.closing.Load() {
+			v.logDebug(c, "action '%s' rejected: session ended", actionID)
+			http.Error(w, "session ended", http.StatusForbidden)
+			return
+		}
 		csrfToken, _ := sigs["via-csrf"].(string)
-		if subtle.ConstantTimeCompare([]byte(csrfToken), []byte(c.csrfToken)) != 1 {
-			v.logWarn(c, "action '%s' rejected: invalid CSRF token", actionID)
-			http.Error(w, "invalid CSRF token", http.StatusForbidden)
+		if err := v.verifyCtxRequest(c, r, csrfToken); err != nil {
+			v.logWarn(c, "action '%s' rejected: %v", actionID, err)
+			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
-		if c.actionLimiter != nil && !c.actionLimiter.Allow() {
+		if (c.actionLimiter != nil && !c.actionLimiter.Allow()) || !v.allowAction(r) {
 			v.logWarn(c, "action '%s' rate limited", actionID)
 			http.Error(w, "rate limited", http.StatusTooManyRequests)
 			return
 		}
-		c.reqCtx = r.Context()
 		entry, err := c.getAction(actionID)
 		if err != nil {
 			v.logDebug(c, "action '%s' failed: %v", actionID, err)
 			return
 		}
+		// the session of the request is authoritative: refresh the user
+		// cached for c.User and c.Can so they agree with the check below
+		c.cacheUser(v.Session(r).User())
+		if entry.permission != "" {
+			if u := c.User(); !v.allow(u, entry.permission) {
+				v.denied(c, AuditEvent{User: u, Permission: entry.permission, Route: c.route, Action: actionID, RemoteAddr: r.RemoteAddr})
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+		}
 		if entry.limiter != nil && !entry.limiter.Allow() {
 			v.logWarn(c, "action '%s' rate limited (per-action)", actionID)
 			http.Error(w, "rate limited", http.StatusTooManyRequests)
@@ -707,24 +887,41 @@ func New() *V {
 			}
 		}()
 
-		c.injectSignals(sigs)
-		entry.fn()
+		c.serialized(func() {
+			// redirects wait for the session changes of the action to be committed
+			release := c.holdRedirects()
+			defer release()
+			c.setRequestCtx(r.Context())
+			defer c.setRequestCtx(nil)
+			c.injectSignals(sigs)
+			entry.fn()
+			v.commitSession(c, r.Context())
+		})
 	})
 
 	v.mux.HandleFunc("POST /_session/close", func(w http.ResponseWriter, r *http.Request) {
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
+		if err := v.checkOrigin(r); err != nil {
+			v.logWarn(nil, "session close rejected: %v", err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		var msg map[string]string
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1024)).Decode(&msg); err != nil {
 			v.logErr(nil, "error reading body: %v", err)
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
 		defer r.Body.Close()
-		cID := string(body)
-		c, err := v.getCtx(cID)
+		c, err := v.getCtx(msg["via-ctx"])
 		if err != nil {
 			v.logErr(c, "failed to handle session close: %v", err)
 			return
 		}
+		if err := v.verifyCtxRequest(c, r, msg["via-csrf"]); err != nil {
+			v.logWarn(c, "session close rejected: %v", err)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
 		v.logDebug(c, "session close event triggered")
 		v.cleanupCtx(c)
 	})
@@ -1,16 +1,21 @@
 package via
 
 import (
+	"bufio"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ryanhamamura/via/h"
+	"github.com/ryanhamamura/via/h/htmltest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPageRoute(t *testing.T) {
@@ -352,7 +357,7 @@ func TestDevModeRemovePersistedFix(t *testing.T) {
 	assert.NoError(t, os.MkdirAll(dir, 0755))
 
 	// Write a persisted context
-	ctxRegMap := map[string]string{"test-ctx-1": "/"}
+	ctxRegMap := map[string]devModeCtx{"test-ctx-1": {Route: "/"}}
 	f, err := os.Create(p)
 	assert.NoError(t, err)
 	assert.NoError(t, json.NewEncoder(f).Encode(ctxRegMap))
@@ -369,7 +374,7 @@ func TestDevModeRemovePersistedFix(t *testing.T) {
 	assert.NoError(t, os.MkdirAll(filepath.Join(".via", "devmode"), 0755))
 	p2 := filepath.Join(".via", "devmode", "ctx.json")
 	f2, _ := os.Create(p2)
-	json.NewEncoder(f2).Encode(map[string]string{"test-ctx-1": "/"})
+	json.NewEncoder(f2).Encode(map[string]devModeCtx{"test-ctx-1": {Route: "/"}})
 	f2.Close()
 
 	c := newContext("test-ctx-1", "/", v)
@@ -379,7 +384,170 @@ func TestDevModeRemovePersistedFix(t *testing.T) {
 	f3, err := os.Open(p2)
 	assert.NoError(t, err)
 	defer f3.Close()
-	var result map[string]string
+	var result map[string]devModeCtx
 	assert.NoError(t, json.NewDecoder(f3).Decode(&result))
 	assert.Empty(t, result, "persisted context should be removed")
 }
+
+func TestDevModeRestore_KeepsPersistedCSRF(t *testing.T) {
+	t.Chdir(t.TempDir())
+	app := func() *V {
+		v := New()
+		v.Config(Options{DevMode: true})
+		v.Page("/", func(c *Context) {
+			c.View(func() h.H { return h.Div() })
+		})
+		return v
+	}
+	v1 := app()
+	v1.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	contexts := v1.Contexts(nil)
+	require.Len(t, contexts, 1)
+	before := contexts[0]
+
+	// a restarted app restores the context with its own token, not the
+	// one sent by the request
+	v2 := app()
+	v2.devModeRestore(before.id)
+	c, err := v2.getCtx(before.id)
+	require.NoError(t, err)
+	assert.Equal(t, before.csrfToken, c.csrfToken)
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.ErrorIs(t, v2.verifyCtxRequest(c, r, "forged"), errInvalidCSRF)
+	assert.NoError(t, v2.verifyCtxRequest(c, r, before.csrfToken))
+}
+
+func TestRenderMode(t *testing.T) {
+	view := func() h.H {
+		return h.Div(h.ID("list"), h.Raw("\n  "), h.Button(h.Disabled(), h.Text("Go")))
+	}
+	for _, tc := range []struct {
+		mode     RenderMode
+		page     string
+		sync     string
+		elements string
+	}{
+		{RenderAsIs,
+			`<div id="list">` + "\n  " + `<button disabled>Go</button></div>`,
+			`<div id="c1"><div id="list">` + "\n  " + `<button disabled>Go</button></div></div>`,
+			`<div id="list">` + "\n  " + `<button disabled>Go</button></div>`},
+		{RenderMinify,
+			`<div id="list"><button disabled>Go</button></div>`,
+			`<div id="c1"><div id="list"><button disabled>Go</button></div></div>`,
+			`<div id="list"><button disabled>Go</button></div>`},
+		{RenderPretty,
+			"\n      <div id=\"list\">\n        <button disabled>Go</button>\n      </div>\n",
+			"<div id=\"c1\">\n  <div id=\"list\">\n    <button disabled>Go</button>\n  </div>\n</div>\n",
+			"<div id=\"list\">\n  <button disabled>Go</button>\n</div>\n"},
+	} {
+		v := New()
+		v.Config(Options{Render: tc.mode})
+		v.Page("/", func(c *Context) { c.View(view) })
+
+		w := httptest.NewRecorder()
+		v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Contains(t, w.Body.String(), tc.page)
+
+		c := newBroadcastCtx(v, "c1", "/", nil)
+		c.View(view)
+		c.Sync()
+		assert.Equal(t, tc.sync, (<-c.patchChan).content)
+		c.SyncElements(view())
+		assert.Equal(t, tc.elements, (<-c.patchChan).content)
+	}
+}
+
+func TestPage_TriggersAndBindings(t *testing.T) {
+	v := New()
+	var ctx *Context
+	var step *signal
+	v.Page("/", func(c *Context) {
+		ctx = c
+		step = c.Signal(1)
+		inc := c.Action(func() {})
+		c.View(func() h.H {
+			return h.Div(h.Input(step.Bind()), h.Button(h.ID("inc"), h.Text("+"), inc.OnClick()))
+		})
+	})
+	w := httptest.NewRecorder()
+	v.mux.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	doc, err := htmltest.Parse(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, step.ID(), doc.Find("input").Bind())
+	id := doc.Find("#inc").Action("click")
+	assert.Contains(t, ctx.actionRegistry, id)
+	assert.Equal(t, "@get('/_sse')", doc.Find("head meta[data-init]").AttrOr("data-init", ""))
+}
+
+func TestPage_StreamsHeadBeforeView(t *testing.T) {
+	v := New()
+	release := make(chan struct{})
+	rows := func(yield func(int) bool) {
+		<-release
+		for i := range 1000 {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+	v.Page("/", func(c *Context) {
+		c.View(func() h.H {
+			return h.Table(h.Each(rows, func(i int) h.H { return h.Tr(h.Td(h.Textf("row %d", i))) }))
+		})
+	})
+	srv := httptest.NewServer(v.mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	head, err := br.ReadString('>')
+	for err == nil && !strings.Contains(head, "</head>") {
+		var more string
+		more, err = br.ReadString('>')
+		head += more
+	}
+	require.NoError(t, err, "head arrives while the view is still blocked")
+	assert.Contains(t, head, `src="/_datastar.js"`)
+
+	close(release)
+	rest, err := io.ReadAll(br)
+	require.NoError(t, err)
+	assert.Contains(t, string(rest), "<td>row 999</td></tr></table>")
+}
+
+func TestPage_StreamsHeadBeforeBuildingView(t *testing.T) {
+	v := New()
+	release := make(chan struct{})
+	blocking := false
+	v.Page("/", func(c *Context) {
+		c.View(func() h.H {
+			if blocking {
+				<-release // a slow view function, e.g. one loading data
+			}
+			return h.P(h.Text("built"))
+		})
+	})
+	blocking = true
+	srv := httptest.NewServer(v.mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	br := bufio.NewReader(resp.Body)
+	var head string
+	for err == nil && !strings.Contains(head, "</head>") {
+		var more string
+		more, err = br.ReadString('>')
+		head += more
+	}
+	require.NoError(t, err, "head arrives before the view function returns")
+
+	close(release)
+	rest, err := io.ReadAll(br)
+	require.NoError(t, err)
+	assert.Contains(t, string(rest), "<p>built</p>")
+}
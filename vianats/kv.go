@@ -0,0 +1,158 @@
+package vianats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ryanhamamura/via"
+)
+
+// KVConfig holds the parameters for creating or binding a JetStream
+// key-value bucket.
+type KVConfig struct {
+	Bucket string
+	// TTL expires values that have not been updated for the given duration.
+	// Zero keeps values forever.
+	TTL time.Duration
+	// History is the number of revisions kept per key. Zero keeps one.
+	History uint8
+}
+
+// KV is a typed view over a JetStream key-value bucket. Values are
+// stored as JSON.
+type KV[T any] struct {
+	kv nats.KeyValue
+}
+
+// NewKV binds to the bucket named in cfg, creating it if it does not exist.
+// It fails if the bucket exists with a different TTL or history.
+func NewKV[T any](n *NATS, cfg KVConfig) (*KV[T], error) {
+	kv, err := bindBucket(n, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &KV[T]{kv: kv}, nil
+}
+
+func bindBucket(n *NATS, cfg KVConfig) (nats.KeyValue, error) {
+	kv, err := n.js.KeyValue(cfg.Bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = n.js.CreateKeyValue(&nats.KeyValueConfig{
+			Bucket:  cfg.Bucket,
+			TTL:     cfg.TTL,
+			History: cfg.History,
+			Storage: n.storage,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vianats: bind kv bucket '%s': %w", cfg.Bucket, err)
+	}
+	status, err := kv.Status()
+	if err != nil {
+		return nil, fmt.Errorf("vianats: bind kv bucket '%s': %w", cfg.Bucket, err)
+	}
+	history := max(int64(cfg.History), 1)
+	if status.TTL() != cfg.TTL || status.History() != history {
+		return nil, fmt.Errorf("vianats: bind kv bucket '%s': exists with ttl %v and history %d, want ttl %v and history %d",
+			cfg.Bucket, status.TTL(), status.History(), cfg.TTL, history)
+	}
+	return kv, nil
+}
+
+// Get returns the current value for key together with its revision.
+// The error wraps nats.ErrKeyNotFound if the key does not exist.
+func (k *KV[T]) Get(key string) (T, uint64, error) {
+	var val T
+	entry, err := k.kv.Get(key)
+	if err != nil {
+		return val, 0, err
+	}
+	if err := json.Unmarshal(entry.Value(), &val); err != nil {
+		return val, 0, fmt.Errorf("vianats: decode kv value '%s': %w", key, err)
+	}
+	return val, entry.Revision(), nil
+}
+
+// Put stores val under key and returns the new revision.
+func (k *KV[T]) Put(key string, val T) (uint64, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return 0, err
+	}
+	return k.kv.Put(key, data)
+}
+
+// CAS stores val under key only if the latest revision of key equals rev.
+// A rev of 0 creates the key and fails if it already exists.
+// The error wraps nats.ErrKeyExists when the revision does not match.
+func (k *KV[T]) CAS(key string, val T, rev uint64) (uint64, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return 0, err
+	}
+	if rev == 0 {
+		return k.kv.Create(key, data)
+	}
+	return k.kv.Update(key, data, rev)
+}
+
+// Delete removes key from the bucket. Previous revisions are kept
+// according to the bucket history.
+func (k *KV[T]) Delete(key string) error {
+	return k.kv.Delete(key)
+}
+
+// Watch calls handler on every change to key after the watch starts and
+// then syncs c to the browser. key may contain NATS wildcards. Deleted
+// keys are reported with the zero value of T. handler runs serialized with
+// the actions of the page, see Context.Run, so it may change page state.
+//
+// The watch is stopped automatically when c is disposed. No-ops during
+// panic-check init.
+func (k *KV[T]) Watch(c *via.Context, key string, handler func(key string, val T)) (via.Subscription, error) {
+	if c.ID() == "" {
+		return nil, nil
+	}
+	w, err := k.kv.Watch(key, nats.UpdatesOnly())
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer w.Stop()
+		for {
+			select {
+			case <-c.Done():
+				return
+			case entry, ok := <-w.Updates():
+				if !ok {
+					return
+				}
+				if entry == nil {
+					continue
+				}
+				var val T
+				if entry.Operation() == nats.KeyValuePut {
+					if err := json.Unmarshal(entry.Value(), &val); err != nil {
+						continue
+					}
+				}
+				c.Run(func(c *via.Context) {
+					handler(entry.Key(), val)
+					c.Sync()
+				})
+			}
+		}
+	}()
+	return &kvWatch{w: w}, nil
+}
+
+type kvWatch struct {
+	w nats.KeyWatcher
+}
+
+func (s *kvWatch) Unsubscribe() error {
+	return s.w.Stop()
+}
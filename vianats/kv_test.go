@@ -0,0 +1,127 @@
+package vianats
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ryanhamamura/via"
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type onCall struct {
+	Name string `json:"name"`
+}
+
+func newTestNATS(t *testing.T) *NATS {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	n, err := New(ctx, t.TempDir(), WithPort(-1))
+	require.NoError(t, err)
+	t.Cleanup(func() { n.Close() })
+	return n
+}
+
+func TestKV_PutGet(t *testing.T) {
+	n := newTestNATS(t)
+	kv, err := NewKV[onCall](n, KVConfig{Bucket: "oncall", History: 5})
+	require.NoError(t, err)
+
+	_, _, err = kv.Get("infra")
+	assert.ErrorIs(t, err, nats.ErrKeyNotFound)
+
+	rev, err := kv.Put("infra", onCall{Name: "alice"})
+	require.NoError(t, err)
+
+	got, gotRev, err := kv.Get("infra")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.Name)
+	assert.Equal(t, rev, gotRev)
+
+	// binding to an existing bucket with the same config reuses it
+	again, err := NewKV[onCall](n, KVConfig{Bucket: "oncall", History: 5})
+	require.NoError(t, err)
+	got, _, err = again.Get("infra")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.Name)
+}
+
+func TestKV_CAS(t *testing.T) {
+	n := newTestNATS(t)
+	kv, err := NewKV[onCall](n, KVConfig{Bucket: "cas"})
+	require.NoError(t, err)
+
+	rev, err := kv.CAS("infra", onCall{Name: "alice"}, 0)
+	require.NoError(t, err)
+
+	_, err = kv.CAS("infra", onCall{Name: "bob"}, 0)
+	assert.Error(t, err, "create should fail when key exists")
+
+	rev2, err := kv.CAS("infra", onCall{Name: "bob"}, rev)
+	require.NoError(t, err)
+	assert.Greater(t, rev2, rev)
+
+	_, err = kv.CAS("infra", onCall{Name: "carol"}, rev)
+	assert.Error(t, err, "stale revision should be rejected")
+
+	got, _, err := kv.Get("infra")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", got.Name)
+}
+
+func TestKV_ExistingBucketConfigMustMatch(t *testing.T) {
+	n := newTestNATS(t)
+	_, err := NewKV[onCall](n, KVConfig{Bucket: "cfg", TTL: time.Minute, History: 3})
+	require.NoError(t, err)
+
+	_, err = NewKV[onCall](n, KVConfig{Bucket: "cfg", TTL: time.Minute, History: 3})
+	assert.NoError(t, err)
+	_, err = NewKV[onCall](n, KVConfig{Bucket: "cfg", TTL: time.Hour, History: 3})
+	assert.ErrorContains(t, err, "exists with ttl")
+	_, err = NewKV[onCall](n, KVConfig{Bucket: "cfg", TTL: time.Minute})
+	assert.ErrorContains(t, err, "history 3")
+}
+
+func TestKV_WatchTriggersHandlerAndStopsOnDispose(t *testing.T) {
+	n := newTestNATS(t)
+	kv, err := NewKV[onCall](n, KVConfig{Bucket: "watch"})
+	require.NoError(t, err)
+
+	updates := make(chan onCall, 4)
+	v := via.New()
+	v.Page("/", func(c *via.Context) {
+		_, err := kv.Watch(c, "infra", func(key string, val onCall) {
+			updates <- val
+		})
+		require.NoError(t, err)
+		c.View(func() h.H { return h.Div() })
+	})
+	w := httptest.NewRecorder()
+	v.HTTPServeMux().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	_, err = kv.Put("infra", onCall{Name: "alice"})
+	require.NoError(t, err)
+
+	select {
+	case got := <-updates:
+		assert.Equal(t, "alice", got.Name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch handler not called")
+	}
+
+	v.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = kv.Put("infra", onCall{Name: "bob"})
+	require.NoError(t, err)
+	select {
+	case got := <-updates:
+		t.Fatalf("handler called after dispose with %v", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,104 @@
+package vianats
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultLimiterBucket is the key-value bucket used for rate limits when no
+// bucket name is given.
+const DefaultLimiterBucket = "via_limits"
+
+// limiterRetries bounds the compare-and-set attempts of one Allow call.
+const limiterRetries = 10
+
+// LimiterStore is a via.LimiterStore backed by a JetStream key-value bucket,
+// so the keyed rate limits of via.Options apply across every instance
+// connected to the same NATS deployment.
+//
+// Each bucket is stored as the time at which it will be full again (the
+// generic cell rate algorithm) and updated with compare-and-set, so
+// concurrent instances never hand out the same token twice.
+//
+// Example:
+//
+//	limits, _ := vianats.NewLimiterStore(n, "")
+//	v.Config(via.Options{
+//		LimiterStore:    limits,
+//		ActionRateLimit: via.RateLimitConfig{Rate: 10, Burst: 20, Key: via.KeyBySession},
+//	})
+type LimiterStore struct {
+	kv nats.KeyValue
+}
+
+// NewLimiterStore binds to bucket, creating it if it does not exist. An
+// empty bucket uses DefaultLimiterBucket. Idle buckets expire after an hour,
+// so limits must refill within that time.
+func NewLimiterStore(n *NATS, bucket string) (*LimiterStore, error) {
+	if bucket == "" {
+		bucket = DefaultLimiterBucket
+	}
+	kv, err := bindBucket(n, KVConfig{Bucket: bucket, TTL: time.Hour})
+	if err != nil {
+		return nil, err
+	}
+	return &LimiterStore{kv: kv}, nil
+}
+
+// Allow takes a token from the bucket of key, which refills at rate tokens
+// per second up to burst, and reports whether one was left.
+func (s *LimiterStore) Allow(key string, rate float64, burst int) (bool, error) {
+	// limit keys may hold characters that are not valid in kv keys, and
+	// custom keys may hold secrets that other bucket readers should not
+	// recover
+	sum := sha256.Sum256([]byte(key))
+	k := hex.EncodeToString(sum[:])
+	interval := time.Duration(float64(time.Second) / rate)
+	tolerance := time.Duration(burst) * interval
+	for range limiterRetries {
+		var (
+			full time.Time
+			rev  uint64
+		)
+		entry, err := s.kv.Get(k)
+		switch {
+		case errors.Is(err, nats.ErrKeyNotFound):
+		case err != nil:
+			return false, fmt.Errorf("vianats: read rate limit: %w", err)
+		default:
+			rev = entry.Revision()
+			if v := entry.Value(); len(v) == 8 {
+				full = time.Unix(0, int64(binary.BigEndian.Uint64(v)))
+			}
+		}
+
+		now := time.Now()
+		if full.Before(now) {
+			full = now
+		}
+		full = full.Add(interval)
+		if full.Sub(now) > tolerance {
+			return false, nil
+		}
+
+		v := binary.BigEndian.AppendUint64(nil, uint64(full.UnixNano()))
+		if rev == 0 {
+			_, err = s.kv.Create(k, v)
+		} else {
+			_, err = s.kv.Update(k, v, rev)
+		}
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, nats.ErrKeyExists) {
+			return false, fmt.Errorf("vianats: update rate limit: %w", err)
+		}
+	}
+	return false, fmt.Errorf("vianats: update rate limit: too much contention on '%s'", key)
+}
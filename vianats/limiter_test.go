@@ -0,0 +1,70 @@
+package vianats
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterStore_BurstThenDeny(t *testing.T) {
+	n := newTestNATS(t)
+	s, err := NewLimiterStore(n, "")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		ok, err := s.Allow("action:ip:192.0.2.1", 0.001, 3)
+		require.NoError(t, err)
+		assert.True(t, ok, "request %d within burst", i)
+	}
+	ok, err := s.Allow("action:ip:192.0.2.1", 0.001, 3)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = s.Allow("action:ip:2001:db8::1", 0.001, 3)
+	require.NoError(t, err)
+	assert.True(t, ok, "keys have their own buckets")
+}
+
+func TestLimiterStore_HashesKeys(t *testing.T) {
+	n := newTestNATS(t)
+	s, err := NewLimiterStore(n, "")
+	require.NoError(t, err)
+	_, err = s.Allow("session:secret-token", 1, 1)
+	require.NoError(t, err)
+
+	keys, err := s.kv.Keys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Len(t, keys[0], 64, "sha256 in hex")
+	assert.NotContains(t, keys[0], "secret")
+}
+
+func TestLimiterStore_SharedBetweenInstances(t *testing.T) {
+	n := newTestNATS(t)
+	a, err := NewLimiterStore(n, "limits")
+	require.NoError(t, err)
+	b, err := NewLimiterStore(n, "limits")
+	require.NoError(t, err)
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		s := a
+		if i%2 == 1 {
+			s = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, err := s.Allow("session:abc", 0.001, 5); err == nil && ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, allowed.Load(), int32(5), "no token is handed out twice")
+	assert.Positive(t, allowed.Load())
+}
@@ -0,0 +1,201 @@
+package vianats
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+const defaultStartTimeout = 10 * time.Second
+
+// Option configures a NATS instance created with New or Connect.
+// Server options only apply to the embedded server started by New.
+type Option func(*options)
+
+type options struct {
+	// embedded server
+	host         string
+	port         int
+	serverName   string
+	clusterName  string
+	clusterHost  string
+	clusterPort  int
+	routes       []string
+	leafPort     int
+	leafRemotes  []string
+	maxMemory    int64
+	maxStore     int64
+	startTimeout time.Duration
+
+	// streams and buckets
+	storage nats.StorageType
+
+	// client connection
+	natsOpts     []nats.Option
+	onDisconnect func(err error)
+	onReconnect  func(url string)
+}
+
+// WithHost sets the interface the embedded server listens on for clients.
+func WithHost(host string) Option {
+	return func(o *options) {
+		o.host = host
+	}
+}
+
+// WithPort sets the client port of the embedded server.
+// Zero uses the NATS default (4222); -1 picks a random free port.
+func WithPort(port int) Option {
+	return func(o *options) {
+		o.port = port
+	}
+}
+
+// WithServerName sets the name of the embedded server. Names must be
+// unique within a cluster.
+func WithServerName(name string) Option {
+	return func(o *options) {
+		o.serverName = name
+	}
+}
+
+// WithCluster runs the embedded server as a node of the named cluster,
+// listening for routes on host:port and connecting to the given route
+// URLs (e.g. "nats://10.0.0.2:6222"). A port of -1 picks a random free port.
+func WithCluster(name, host string, port int, routes ...string) Option {
+	return func(o *options) {
+		o.clusterName = name
+		o.clusterHost = host
+		o.clusterPort = port
+		o.routes = routes
+	}
+}
+
+// WithLeafNodePort accepts leaf node connections on the given port.
+func WithLeafNodePort(port int) Option {
+	return func(o *options) {
+		o.leafPort = port
+	}
+}
+
+// WithStartTimeout sets how long New waits for the embedded server to accept
+// connections, 10 seconds by default. Cluster nodes with JetStream are only
+// ready once they see their peers.
+func WithStartTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.startTimeout = d
+	}
+}
+
+// WithLeafNodeRemotes connects the embedded server as a leaf node to the
+// given hub URLs (e.g. "nats-leaf://hub:7422").
+func WithLeafNodeRemotes(urls ...string) Option {
+	return func(o *options) {
+		o.leafRemotes = urls
+	}
+}
+
+// WithJetStreamLimits caps the memory and file storage the embedded
+// server uses for JetStream. Zero leaves a limit unset.
+func WithJetStreamLimits(maxMemory, maxStore int64) Option {
+	return func(o *options) {
+		o.maxMemory = maxMemory
+		o.maxStore = maxStore
+	}
+}
+
+// WithMemoryStorage makes streams and KV buckets created through this
+// package keep their data in memory instead of on disk.
+func WithMemoryStorage() Option {
+	return func(o *options) {
+		o.storage = nats.MemoryStorage
+	}
+}
+
+// WithCredentials authenticates the client with a NATS credentials file.
+func WithCredentials(file string) Option {
+	return func(o *options) {
+		o.natsOpts = append(o.natsOpts, nats.UserCredentials(file))
+	}
+}
+
+// WithTLS secures the client connection with the given TLS config.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.natsOpts = append(o.natsOpts, nats.Secure(cfg))
+	}
+}
+
+// WithReconnect sets how often and how long apart the client tries to
+// reconnect after losing its connection. A max of -1 retries forever.
+func WithReconnect(max int, wait time.Duration) Option {
+	return func(o *options) {
+		o.natsOpts = append(o.natsOpts, nats.MaxReconnects(max), nats.ReconnectWait(wait))
+	}
+}
+
+// WithDisconnectHandler calls fn whenever the client loses its connection.
+// The event is logged regardless.
+func WithDisconnectHandler(fn func(err error)) Option {
+	return func(o *options) {
+		o.onDisconnect = fn
+	}
+}
+
+// WithReconnectHandler calls fn with the server URL whenever the client
+// reconnects. The event is logged regardless.
+func WithReconnectHandler(fn func(url string)) Option {
+	return func(o *options) {
+		o.onReconnect = fn
+	}
+}
+
+// WithNATSOptions passes additional options to the underlying nats.Connect.
+func WithNATSOptions(opts ...nats.Option) Option {
+	return func(o *options) {
+		o.natsOpts = append(o.natsOpts, opts...)
+	}
+}
+
+func (o *options) serverOptions(dataDir string) (*server.Options, error) {
+	so := &server.Options{
+		JetStream:          true,
+		StoreDir:           dataDir,
+		Host:               o.host,
+		Port:               o.port,
+		ServerName:         o.serverName,
+		JetStreamMaxMemory: o.maxMemory,
+		JetStreamMaxStore:  o.maxStore,
+	}
+	if o.clusterName != "" {
+		so.Cluster = server.ClusterOpts{
+			Name: o.clusterName,
+			Host: o.clusterHost,
+			Port: o.clusterPort,
+		}
+		for _, r := range o.routes {
+			u, err := url.Parse(r)
+			if err != nil {
+				return nil, fmt.Errorf("vianats: parse route '%s': %w", r, err)
+			}
+			so.Routes = append(so.Routes, u)
+		}
+	}
+	so.LeafNode.Port = o.leafPort
+	if len(o.leafRemotes) > 0 {
+		remote := &server.RemoteLeafOpts{}
+		for _, r := range o.leafRemotes {
+			u, err := url.Parse(r)
+			if err != nil {
+				return nil, fmt.Errorf("vianats: parse leaf node remote '%s': %w", r, err)
+			}
+			remote.URLs = append(remote.URLs, u)
+		}
+		so.LeafNode.Remotes = []*server.RemoteLeafOpts{remote}
+	}
+	return so, nil
+}
@@ -0,0 +1,169 @@
+package vianats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultSessionBucket is the key-value bucket used for sessions when no
+// bucket name is given.
+const DefaultSessionBucket = "via_sessions"
+
+// SessionStore is an scs session store backed by a JetStream key-value
+// bucket, so every instance connected to the same NATS deployment shares
+// sessions.
+type SessionStore struct {
+	kv          nats.KeyValue
+	stopCleanup chan struct{}
+}
+
+// NewSessionStore binds to bucket, creating it if it does not exist. An
+// empty bucket uses DefaultSessionBucket. Expired sessions are removed every
+// cleanupInterval; zero disables the cleanup goroutine.
+func NewSessionStore(n *NATS, bucket string, cleanupInterval time.Duration) (*SessionStore, error) {
+	if bucket == "" {
+		bucket = DefaultSessionBucket
+	}
+	kv, err := bindBucket(n, KVConfig{Bucket: bucket})
+	if err != nil {
+		return nil, err
+	}
+	s := &SessionStore{kv: kv}
+	if cleanupInterval > 0 {
+		s.stopCleanup = make(chan struct{})
+		go s.startCleanup(cleanupInterval, s.stopCleanup)
+	}
+	return s, nil
+}
+
+// NewSessionManager creates a session manager that stores sessions in
+// bucket and removes expired ones every five minutes. Configure it further
+// (Lifetime, Cookie settings, etc.) before passing it to
+// via.Options.SessionManager.
+func NewSessionManager(n *NATS, bucket string) (*scs.SessionManager, error) {
+	store, err := NewSessionStore(n, bucket, 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	sm := scs.New()
+	sm.Store = store
+	return sm, nil
+}
+
+// Find returns the data for a session token. Expired sessions are not found.
+func (s *SessionStore) Find(token string) ([]byte, bool, error) {
+	entry, err := s.kv.Get(token)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("vianats: find session: %w", err)
+	}
+	v := entry.Value()
+	if sessionExpired(v, time.Now()) {
+		return nil, false, nil
+	}
+	return v[8:], true, nil
+}
+
+// Commit stores the data for a session token until expiry.
+func (s *SessionStore) Commit(token string, b []byte, expiry time.Time) error {
+	v := make([]byte, 8+len(b))
+	binary.BigEndian.PutUint64(v, uint64(expiry.UnixNano()))
+	copy(v[8:], b)
+	if _, err := s.kv.Put(token, v); err != nil {
+		return fmt.Errorf("vianats: commit session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a session token and its data.
+func (s *SessionStore) Delete(token string) error {
+	if err := s.kv.Purge(token); err != nil {
+		return fmt.Errorf("vianats: delete session: %w", err)
+	}
+	return nil
+}
+
+// All returns the data of every session that has not expired.
+func (s *SessionStore) All() (map[string][]byte, error) {
+	all := make(map[string][]byte)
+	now := time.Now()
+	err := s.each(func(token string, v []byte) {
+		if !sessionExpired(v, now) {
+			all[token] = v[8:]
+		}
+	})
+	return all, err
+}
+
+// StopCleanup stops the cleanup goroutine.
+func (s *SessionStore) StopCleanup() {
+	if s.stopCleanup != nil {
+		close(s.stopCleanup)
+		s.stopCleanup = nil
+	}
+}
+
+// each calls fn with the raw value of every stored session.
+func (s *SessionStore) each(fn func(token string, v []byte)) error {
+	keys, err := s.kv.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("vianats: list sessions: %w", err)
+	}
+	for _, k := range keys {
+		entry, err := s.kv.Get(k)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("vianats: list sessions: %w", err)
+		}
+		fn(k, entry.Value())
+	}
+	return nil
+}
+
+func (s *SessionStore) startCleanup(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.deleteExpired()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *SessionStore) deleteExpired() error {
+	now := time.Now()
+	var expired []string
+	if err := s.each(func(token string, v []byte) {
+		if sessionExpired(v, now) {
+			expired = append(expired, token)
+		}
+	}); err != nil {
+		return err
+	}
+	for _, token := range expired {
+		if err := s.kv.Purge(token); err != nil {
+			return err
+		}
+	}
+	// drop the purge markers left behind by deleted sessions
+	return s.kv.PurgeDeletes(nats.DeleteMarkersOlderThan(-1))
+}
+
+func sessionExpired(v []byte, now time.Time) bool {
+	return len(v) < 8 || int64(binary.BigEndian.Uint64(v)) < now.UnixNano()
+}
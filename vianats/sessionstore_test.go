@@ -0,0 +1,27 @@
+package vianats
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/ryanhamamura/via/internal/sessiontest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStore_Conformance(t *testing.T) {
+	n := newTestNATS(t)
+	buckets := 0
+	sessiontest.Run(t, func(t *testing.T, cleanup time.Duration) (scs.Store, func() int) {
+		buckets++
+		s, err := NewSessionStore(n, fmt.Sprintf("sessions_%d", buckets), cleanup)
+		require.NoError(t, err)
+		t.Cleanup(s.StopCleanup)
+		stored := func() int {
+			keys, _ := s.kv.Keys()
+			return len(keys)
+		}
+		return s, stored
+	})
+}
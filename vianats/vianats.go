@@ -1,49 +1,126 @@
-// Package vianats provides an embedded NATS server with JetStream as a
-// pub/sub backend for Via applications.
+// Package vianats provides NATS with JetStream as a pub/sub backend for Via
+// applications, using either an embedded server or an external deployment.
 package vianats
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 	"time"
 
-	"github.com/delaneyj/toolbelt/embeddednats"
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog"
 	"github.com/ryanhamamura/via"
 )
 
-// NATS implements via.PubSub using an embedded NATS server with JetStream.
+// NATS implements via.PubSub on top of a NATS connection with JetStream,
+// either to an embedded server or to an external deployment.
 type NATS struct {
-	server *embeddednats.Server
-	nc     *nats.Conn
-	js     nats.JetStreamContext
+	server  *server.Server
+	nc      *nats.Conn
+	js      nats.JetStreamContext
+	storage nats.StorageType
+	logger  atomic.Pointer[zerolog.Logger]
 }
 
 // New starts an embedded NATS server with JetStream enabled and returns a
 // ready-to-use NATS instance. The server stores data in dataDir and shuts
-// down when ctx is cancelled.
-func New(ctx context.Context, dataDir string) (*NATS, error) {
-	ns, err := embeddednats.New(ctx, embeddednats.WithDirectory(dataDir))
+// down when ctx is cancelled. It fails if the server options are invalid
+// or the server is not ready within the start timeout, e.g. because its
+// port is in use.
+func New(ctx context.Context, dataDir string, opts ...Option) (*NATS, error) {
+	o := &options{startTimeout: defaultStartTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	so, err := o.serverOptions(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := server.NewServer(so)
 	if err != nil {
 		return nil, fmt.Errorf("vianats: start server: %w", err)
 	}
-	ns.WaitForServer()
+	go func() {
+		<-ctx.Done()
+		ns.Shutdown()
+	}()
+	ns.Start()
+	if !ns.ReadyForConnections(o.startTimeout) {
+		ns.Shutdown()
+		return nil, fmt.Errorf("vianats: start server: not ready for connections within %v", o.startTimeout)
+	}
 
-	nc, err := ns.Client()
+	n, err := connect(ns.ClientURL(), o)
+	if err != nil {
+		ns.Shutdown()
+		return nil, err
+	}
+	n.server = ns
+	return n, nil
+}
+
+// Connect connects to an existing NATS deployment at url (a comma separated
+// list of server URLs is accepted) instead of starting an embedded server.
+// JetStream must be enabled on the deployment for streams and KV buckets.
+func Connect(url string, opts ...Option) (*NATS, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return connect(url, o)
+}
+
+func connect(url string, o *options) (*NATS, error) {
+	n := &NATS{storage: o.storage}
+	nop := zerolog.Nop()
+	n.logger.Store(&nop)
+
+	natsOpts := append([]nats.Option{
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			evt := n.logger.Load().Warn()
+			if err != nil {
+				evt = evt.Err(err)
+			}
+			evt.Msg("nats disconnected")
+			if o.onDisconnect != nil {
+				o.onDisconnect(err)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			n.logger.Load().Info().Msgf("nats reconnected to %s", nc.ConnectedUrl())
+			if o.onReconnect != nil {
+				o.onReconnect(nc.ConnectedUrl())
+			}
+		}),
+		nats.ClosedHandler(func(_ *nats.Conn) {
+			n.logger.Load().Debug().Msg("nats connection closed")
+		}),
+	}, o.natsOpts...)
+
+	nc, err := nats.Connect(url, natsOpts...)
 	if err != nil {
-		ns.Close()
 		return nil, fmt.Errorf("vianats: connect client: %w", err)
 	}
 
 	js, err := nc.JetStream()
 	if err != nil {
 		nc.Close()
-		ns.Close()
 		return nil, fmt.Errorf("vianats: init jetstream: %w", err)
 	}
 
-	return &NATS{server: ns, nc: nc, js: js}, nil
+	n.nc = nc
+	n.js = js
+	return n, nil
+}
+
+// SetLogger sets the logger used to report connection events. Via calls it
+// with the application logger when the NATS instance is configured as PubSub.
+func (n *NATS) SetLogger(l zerolog.Logger) {
+	n.logger.Store(&l)
 }
 
 // Publish sends data to the given subject using core NATS publish.
@@ -63,10 +140,13 @@ func (n *NATS) Subscribe(subject string, handler func(data []byte)) (via.Subscri
 	return sub, nil
 }
 
-// Close shuts down the client connection and embedded server.
+// Close shuts down the client connection and, if present, the embedded server.
 func (n *NATS) Close() error {
 	n.nc.Close()
-	return n.server.Close()
+	if n.server != nil {
+		n.server.Shutdown()
+	}
+	return nil
 }
 
 // Conn returns the underlying NATS connection for advanced usage.
@@ -95,6 +175,7 @@ func EnsureStream(n *NATS, cfg StreamConfig) error {
 		Retention: nats.LimitsPolicy,
 		MaxMsgs:   cfg.MaxMsgs,
 		MaxAge:    cfg.MaxAge,
+		Storage:   n.storage,
 	})
 	return err
 }
@@ -0,0 +1,149 @@
+package vianats
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect_ExternalServer(t *testing.T) {
+	embedded := newTestNATS(t)
+
+	n, err := Connect(embedded.server.ClientURL(), WithReconnect(-1, 10*time.Millisecond))
+	require.NoError(t, err)
+	defer n.Close()
+	assert.Nil(t, n.server)
+
+	got := make(chan []byte, 1)
+	_, err = embedded.Subscribe("ext.topic", func(data []byte) { got <- data })
+	require.NoError(t, err)
+	require.NoError(t, embedded.Conn().Flush())
+
+	require.NoError(t, n.Publish("ext.topic", []byte("hello")))
+	select {
+	case data := <-got:
+		assert.Equal(t, []byte("hello"), data)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not delivered")
+	}
+}
+
+func TestConnect_InvalidURL(t *testing.T) {
+	_, err := Connect("nats://127.0.0.1:1", WithNATSOptions())
+	assert.Error(t, err)
+}
+
+func TestNew_Cluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// JetStream cluster nodes only report ready once they see their peers,
+	// so both nodes are started concurrently on known route ports.
+	portA, portB := freePort(t), freePort(t)
+	routeA := fmt.Sprintf("nats://127.0.0.1:%d", portA)
+	routeB := fmt.Sprintf("nats://127.0.0.1:%d", portB)
+
+	type result struct {
+		n   *NATS
+		err error
+	}
+	start := func(name string, port int, route string) chan result {
+		ch := make(chan result, 1)
+		go func() {
+			n, err := New(ctx, t.TempDir(),
+				WithPort(-1), WithServerName(name),
+				WithCluster("via", "127.0.0.1", port, route))
+			ch <- result{n, err}
+		}()
+		return ch
+	}
+	chA := start("a", portA, routeB)
+	chB := start("b", portB, routeA)
+	resA, resB := <-chA, <-chB
+	require.NoError(t, resA.err)
+	require.NoError(t, resB.err)
+	a, b := resA.n, resB.n
+	defer a.Close()
+	defer b.Close()
+
+	var received atomic.Int32
+	_, err := b.Subscribe("cluster.topic", func(data []byte) { received.Add(1) })
+	require.NoError(t, err)
+	require.NoError(t, b.Conn().Flush())
+
+	assert.Eventually(t, func() bool {
+		_ = a.Publish("cluster.topic", []byte("ping"))
+		return received.Load() > 0
+	}, 5*time.Second, 50*time.Millisecond, "message published on node a should reach node b")
+}
+
+func TestNew_InvalidServerOptions(t *testing.T) {
+	_, err := New(context.Background(), t.TempDir(), WithPort(-1), WithServerName("my server"))
+	assert.ErrorContains(t, err, "vianats: start server")
+}
+
+func TestNew_PortInUse(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = New(context.Background(), t.TempDir(),
+		WithHost("127.0.0.1"), WithPort(l.Addr().(*net.TCPAddr).Port), WithStartTimeout(200*time.Millisecond))
+	assert.ErrorContains(t, err, "not ready for connections")
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestDisconnectIsLoggedAndReported(t *testing.T) {
+	embedded := newTestNATS(t)
+
+	disconnected := make(chan struct{}, 1)
+	n, err := Connect(embedded.server.ClientURL(),
+		WithReconnect(0, 0),
+		WithDisconnectHandler(func(err error) { disconnected <- struct{}{} }))
+	require.NoError(t, err)
+	defer n.Close()
+
+	var buf safeBuffer
+	n.SetLogger(zerolog.New(&buf))
+
+	embedded.server.Shutdown()
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("disconnect handler not called")
+	}
+	assert.Contains(t, buf.String(), "nats disconnected")
+}
+
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
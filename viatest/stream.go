@@ -0,0 +1,326 @@
+package viatest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/ryanhamamura/via/internal/htmlparse"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// PatchType is the kind of a patch sent to a page.
+type PatchType int
+
+const (
+	// PatchElements patches elements of the DOM, e.g. a sync of the view.
+	PatchElements PatchType = iota
+	// PatchSignals sets signals in the browser.
+	PatchSignals
+	// PatchScript runs a script, see Context.ExecScript.
+	PatchScript
+	// PatchRedirect navigates to another page, see Context.Redirect.
+	PatchRedirect
+	// PatchReplaceURL replaces the URL in the address bar, see
+	// Context.ReplaceURL.
+	PatchReplaceURL
+)
+
+func (t PatchType) String() string {
+	switch t {
+	case PatchElements:
+		return "elements"
+	case PatchSignals:
+		return "signals"
+	case PatchScript:
+		return "script"
+	case PatchRedirect:
+		return "redirect"
+	case PatchReplaceURL:
+		return "replace url"
+	}
+	return "unknown"
+}
+
+// Patch is a patch received over the SSE stream of a page.
+type Patch struct {
+	Type PatchType
+	// Elements, Selector and Mode are set for PatchElements. Mode is a
+	// Datastar patch mode, "outer" by default.
+	Elements string
+	Selector string
+	Mode     string
+	// Signals is set for PatchSignals.
+	Signals map[string]any
+	// Script is set for PatchScript.
+	Script string
+	// URL is set for PatchRedirect and PatchReplaceURL.
+	URL string
+}
+
+// read reads the SSE stream of the page until it is closed.
+func (p *Page) read(body io.ReadCloser) {
+	defer close(p.done)
+	defer body.Close()
+	r := bufio.NewReader(body)
+	var event string
+	var data []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if event != "" {
+				p.receive(event, data)
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+// receive records the event with the data lines and applies it to the
+// page.
+func (p *Page) receive(event string, data []string) {
+	var patch Patch
+	switch event {
+	case "datastar-patch-elements":
+		var elems []string
+		for _, d := range data {
+			key, val, _ := strings.Cut(d, " ")
+			switch key {
+			case "selector":
+				patch.Selector = val
+			case "mode":
+				patch.Mode = val
+			case "elements":
+				elems = append(elems, val)
+			}
+		}
+		if len(elems) == 0 {
+			// the event opening the stream carries no elements
+			return
+		}
+		patch.Type, patch.Elements = PatchElements, strings.Join(elems, "\n")
+		if patch.Mode == "" {
+			patch.Mode = "outer"
+		}
+		patch = scriptPatch(patch)
+		if m := settleMarkerRe.FindStringSubmatch(patch.Script); patch.Type == PatchScript && m != nil {
+			n, _ := strconv.Atoi(m[1])
+			p.mu.Lock()
+			p.settled = max(p.settled, n)
+			p.mu.Unlock()
+			return
+		}
+	case "datastar-patch-signals":
+		var sigs []string
+		for _, d := range data {
+			if val, ok := strings.CutPrefix(d, "signals "); ok {
+				sigs = append(sigs, val)
+			}
+		}
+		patch.Type = PatchSignals
+		if err := json.Unmarshal([]byte(strings.Join(sigs, "\n")), &patch.Signals); err != nil {
+			p.t.Errorf("viatest: signals patch: %v", err)
+			return
+		}
+	default:
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.patches = append(p.patches, patch)
+	switch patch.Type {
+	case PatchElements:
+		if err := patchElements(p.doc, patch); err != nil {
+			p.t.Errorf("viatest: %v", err)
+		}
+	case PatchSignals:
+		mergeSignals(p.signals, patch.Signals)
+	}
+}
+
+// settleMarker is the script Page.Settle sends through the stream.
+const settleMarker = "/* viatest settle %d */"
+
+var settleMarkerRe = regexp.MustCompile(`^/\* viatest settle (\d+) \*/$`)
+
+var (
+	redirectRe   = regexp.MustCompile(`^setTimeout\(\(\) => window\.location\.href = ("(?:[^"\\]|\\.)*")\)$`)
+	replaceURLRe = regexp.MustCompile(`^window\.history\.replaceState\(\{\}, "", ("(?:[^"\\]|\\.)*")\)$`)
+)
+
+// scriptPatch returns patch as the script, redirect or URL replacement it
+// carries, which are sent as a script element appended to the body.
+func scriptPatch(patch Patch) Patch {
+	if patch.Selector != "body" || patch.Mode != "append" {
+		return patch
+	}
+	nodes, err := parseElements(patch.Elements)
+	if err != nil || len(nodes) != 1 || nodes[0].DataAtom != atom.Script {
+		return patch
+	}
+	var script strings.Builder
+	for c := nodes[0].FirstChild; c != nil; c = c.NextSibling {
+		script.WriteString(c.Data)
+	}
+	s := script.String()
+	if m := redirectRe.FindStringSubmatch(s); m != nil {
+		if u, err := strconv.Unquote(m[1]); err == nil {
+			return Patch{Type: PatchRedirect, URL: u}
+		}
+	}
+	if m := replaceURLRe.FindStringSubmatch(s); m != nil {
+		if u, err := strconv.Unquote(m[1]); err == nil {
+			return Patch{Type: PatchReplaceURL, URL: u}
+		}
+	}
+	return Patch{Type: PatchScript, Script: s}
+}
+
+func parseElements(s string) ([]*html.Node, error) {
+	return htmlparse.Parse(strings.NewReader(s))
+}
+
+// patchElements applies an element patch to doc. Without a selector each
+// top level element patches the element with its id, as in Datastar;
+// morphs are applied as replacements.
+func patchElements(doc *html.Node, patch Patch) error {
+	if patch.Selector == "" {
+		nodes, err := parseElements(patch.Elements)
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			if n.Type != html.ElementNode {
+				continue
+			}
+			id := attr(n, "id")
+			if id == "" {
+				return fmt.Errorf("patch elements: <%s> has no id and no selector is given", n.Data)
+			}
+			target := byID(doc, id)
+			if target == nil {
+				return fmt.Errorf("patch elements: no element matches #%s", id)
+			}
+			if err := applyMode(patch.Mode, target, []*html.Node{n}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	sel, err := cascadia.Compile(patch.Selector)
+	if err != nil {
+		return fmt.Errorf("patch elements: %w", err)
+	}
+	targets := sel.MatchAll(doc)
+	if len(targets) == 0 {
+		return fmt.Errorf("patch elements: no element matches %s", patch.Selector)
+	}
+	for _, target := range targets {
+		// each target gets its own copy of the elements
+		nodes, err := parseElements(patch.Elements)
+		if err != nil {
+			return err
+		}
+		if err := applyMode(patch.Mode, target, nodes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMode(mode string, target *html.Node, nodes []*html.Node) error {
+	switch mode {
+	case "outer", "replace":
+		for _, n := range nodes {
+			target.Parent.InsertBefore(n, target)
+		}
+		target.Parent.RemoveChild(target)
+	case "inner":
+		for c := target.FirstChild; c != nil; c = target.FirstChild {
+			target.RemoveChild(c)
+		}
+		for _, n := range nodes {
+			target.AppendChild(n)
+		}
+	case "append":
+		for _, n := range nodes {
+			target.AppendChild(n)
+		}
+	case "prepend":
+		first := target.FirstChild
+		for _, n := range nodes {
+			target.InsertBefore(n, first)
+		}
+	case "before":
+		for _, n := range nodes {
+			target.Parent.InsertBefore(n, target)
+		}
+	case "after":
+		next := target.NextSibling
+		for _, n := range nodes {
+			target.Parent.InsertBefore(n, next)
+		}
+	case "remove":
+		target.Parent.RemoveChild(target)
+	default:
+		return fmt.Errorf("patch elements: unknown mode '%s'", mode)
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func byID(n *html.Node, id string) *html.Node {
+	if n.Type == html.ElementNode && attr(n, "id") == id {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := byID(c, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// mergeSignals applies a signals patch to sigs as a JSON merge patch:
+// objects are merged and null removes a signal.
+func mergeSignals(sigs, patch map[string]any) {
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(sigs, k)
+		case map[string]any:
+			sub, ok := sigs[k].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				sigs[k] = sub
+			}
+			mergeSignals(sub, v)
+		default:
+			sigs[k] = v
+		}
+	}
+}
@@ -0,0 +1,442 @@
+// Package viatest runs Via pages headless in tests. A page is loaded over
+// HTTP as a browser would load it: its SSE stream is kept open, the patches
+// it receives are recorded in order and applied to an in-memory DOM, and
+// actions are invoked through the elements that trigger them.
+//
+// Example:
+//
+//	p := viatest.Load(t, v, "/")
+//	p.SetSignal(step.ID(), 5)
+//	p.Click("#inc")
+//	assert.Equal(t, "5", p.Find("#count").Text())
+//	assert.Equal(t, viatest.PatchElements, p.Patches()[0].Type)
+//
+// Expressions are not evaluated, apart from the signal assignments of the
+// triggers; computed signals and client side behaviour are left to browser
+// tests.
+package viatest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ryanhamamura/via"
+	"github.com/ryanhamamura/via/h/htmltest"
+	"golang.org/x/net/html"
+)
+
+const (
+	// timeout bounds every wait for the page.
+	timeout = 5 * time.Second
+	// markerRetry is how long Settle waits for its marker before sending
+	// another, since patches are dropped while the stream buffer is full.
+	markerRetry = 100 * time.Millisecond
+)
+
+// Client is a browser session of an app: the pages it loads share its
+// cookies.
+type Client struct {
+	t    testing.TB
+	v    *via.V
+	srv  *httptest.Server
+	http *http.Client
+}
+
+// New serves v until the test ends and returns a client for it.
+func New(t testing.TB, v *via.V) *Client {
+	t.Helper()
+	srv := httptest.NewServer(v.Handler())
+	t.Cleanup(srv.Close)
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("viatest: cookie jar: %v", err)
+	}
+	return &Client{t: t, v: v, srv: srv, http: &http.Client{Jar: jar}}
+}
+
+// URL returns the address the app is served at.
+func (cl *Client) URL() string {
+	return cl.srv.URL
+}
+
+// Load loads the page at path in a new session. See Client.Load.
+func Load(t testing.TB, v *via.V, path string) *Page {
+	t.Helper()
+	return New(t, v).Load(path)
+}
+
+// Page is a page loaded by a Client, with its SSE stream connected.
+type Page struct {
+	t      testing.TB
+	client *Client
+	ctx    *via.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	doc     *html.Node
+	signals map[string]any
+	patches []Patch
+	marker  int // last settle marker sent
+	settled int // last settle marker received
+}
+
+// Load loads the page at path and connects its SSE stream. It returns once
+// the page has received the view synced on connect and has settled, see
+// Page.Settle. The stream is closed when the test ends.
+func (cl *Client) Load(path string) *Page {
+	t := cl.t
+	t.Helper()
+	resp, err := cl.http.Get(cl.srv.URL + path)
+	if err != nil {
+		t.Fatalf("viatest: load %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("viatest: load %s: %s", path, resp.Status)
+	}
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		t.Fatalf("viatest: load %s: %v", path, err)
+	}
+	p := &Page{t: t, client: cl, doc: doc, signals: make(map[string]any)}
+	readSignals(doc, p.signals)
+
+	id, _ := p.signals["via-ctx"].(string)
+	ctxs := cl.v.Contexts(via.ByID(id))
+	if len(ctxs) != 1 {
+		t.Fatalf("viatest: load %s: no live context '%s'", path, id)
+	}
+	p.ctx = ctxs[0]
+
+	p.connect()
+	p.WaitFor(func() bool {
+		for _, patch := range p.Patches() {
+			if patch.Type == PatchElements {
+				return true
+			}
+		}
+		return false
+	})
+	p.Settle()
+	return p
+}
+
+// connect opens the SSE stream of the page, as the data-init of the page
+// does in the browser.
+func (p *Page) connect() {
+	p.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", p.client.srv.URL+"/_sse?datastar="+url.QueryEscape(p.requestSignals()), nil)
+	if err != nil {
+		cancel()
+		p.t.Fatalf("viatest: connect: %v", err)
+	}
+	resp, err := p.client.http.Do(req)
+	if err != nil {
+		cancel()
+		p.t.Fatalf("viatest: connect: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		p.t.Fatalf("viatest: connect: %s", resp.Status)
+	}
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.read(resp.Body)
+	p.t.Cleanup(p.Close)
+}
+
+// Close closes the SSE stream of the page, which disposes its context as
+// closing the browser tab would.
+func (p *Page) Close() {
+	p.once.Do(func() {
+		p.cancel()
+		<-p.done
+	})
+}
+
+// Context returns the context of the page.
+func (p *Page) Context() *via.Context {
+	return p.ctx
+}
+
+// Signal returns the value of signal id in the browser.
+func (p *Page) Signal(id string) (any, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v, ok := p.signals[id]
+	return v, ok
+}
+
+// SetSignal sets signal id in the browser, as typing into an input bound to
+// it would. The value reaches the server with the next action.
+func (p *Page) SetSignal(id string, value any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.signals[id] = value
+}
+
+// Click clicks the first element matching selector. See Trigger.
+func (p *Page) Click(selector string) {
+	p.t.Helper()
+	p.Trigger(selector, "click")
+}
+
+// Trigger fires event on the first element matching selector in the
+// current DOM: the signal assignments of its data-on attribute are applied,
+// e.g. those of via.WithSignal, and the action it calls is invoked. It
+// fails the test if no element matches or the element calls no action.
+func (p *Page) Trigger(selector, event string) {
+	p.t.Helper()
+	el := p.Find(selector).First()
+	if !el.Exists() {
+		p.t.Fatalf("viatest: no element matches %s", selector)
+	}
+	id := el.Action(event)
+	if id == "" {
+		p.t.Fatalf("viatest: %s calls no action on %s", selector, event)
+	}
+	expr, _ := el.Data("on:" + event)
+	p.mu.Lock()
+	assign(p.signals, expr)
+	p.mu.Unlock()
+	p.Action(id)
+}
+
+// Action invokes the action with id, sending the signals of the page, and
+// waits for the patches it sent to be applied, see Settle. It fails the test if the action is
+// rejected, e.g. for missing permission or a rate limit.
+func (p *Page) Action(id string) {
+	p.t.Helper()
+	resp, err := p.client.http.Get(p.client.srv.URL + "/_action/" + id + "?datastar=" + url.QueryEscape(p.requestSignals()))
+	if err != nil {
+		p.t.Fatalf("viatest: action '%s': %v", id, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		p.t.Fatalf("viatest: action '%s': %s", id, resp.Status)
+	}
+	p.Settle()
+}
+
+// requestSignals returns the signals sent with requests, as Datastar sends
+// them: all but those starting with an underscore.
+func (p *Page) requestSignals() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sigs := make(map[string]any, len(p.signals))
+	for k, v := range p.signals {
+		if !strings.HasPrefix(k, "_") {
+			sigs[k] = v
+		}
+	}
+	b, _ := json.Marshal(sigs)
+	return string(b)
+}
+
+// Patches returns the patches received since the page loaded, or since
+// the last ClearPatches, in order.
+func (p *Page) Patches() []Patch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Patch(nil), p.patches...)
+}
+
+// ClearPatches forgets the patches received so far, e.g. those of the load.
+func (p *Page) ClearPatches() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.patches = nil
+}
+
+// Settle waits until the patches sent to the page before the call have
+// been applied. It sends a marker script over the SSE stream of the page
+// and waits for it to arrive after them. Patches sent later, e.g. from
+// goroutines started by an action, need WaitFor.
+func (p *Page) Settle() {
+	p.t.Helper()
+	deadline := time.Now().Add(timeout)
+	p.mu.Lock()
+	first := p.marker + 1
+	p.mu.Unlock()
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		p.marker++
+		marker := p.marker
+		p.mu.Unlock()
+		p.ctx.ExecScript(fmt.Sprintf(settleMarker, marker))
+		retry := time.Now().Add(markerRetry)
+		for time.Now().Before(retry) {
+			p.mu.Lock()
+			settled := p.settled >= first
+			p.mu.Unlock()
+			if settled {
+				return
+			}
+			select {
+			case <-p.done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+	p.t.Fatalf("viatest: page did not settle within %v", timeout)
+}
+
+// WaitFor waits until cond returns true, e.g. for a patch sent from a
+// goroutine, and fails the test if it does not within five seconds.
+func (p *Page) WaitFor(cond func() bool) {
+	p.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			p.t.Fatalf("viatest: condition not met within %v", timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// DOM returns a snapshot of the current document of the page.
+func (p *Page) DOM() *htmltest.Selection {
+	p.t.Helper()
+	s, err := htmltest.Parse(strings.NewReader(p.HTML()))
+	if err != nil {
+		p.t.Fatalf("viatest: parse: %v", err)
+	}
+	return s
+}
+
+// Find returns the elements of the current document matching the CSS
+// selector.
+func (p *Page) Find(selector string) *htmltest.Selection {
+	p.t.Helper()
+	return p.DOM().Find(selector)
+}
+
+// HTML returns the current document of the page.
+func (p *Page) HTML() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var b bytes.Buffer
+	_ = html.Render(&b, p.doc)
+	return b.String()
+}
+
+var quotedPairRe = regexp.MustCompile(`'([^']*)'\s*:\s*'([^']*)'`)
+
+// readSignals adds the signals declared with data-signals attributes in doc
+// to sigs. Values that are no JSON, such as the single quoted object of
+// the page meta tag, are read as string pairs.
+func readSignals(n *html.Node, sigs map[string]any) {
+	if n.Type == html.ElementNode {
+		for _, a := range n.Attr {
+			if a.Key != "data-signals" && !strings.HasPrefix(a.Key, "data-signals__") {
+				continue
+			}
+			ifMissing := strings.Contains(a.Key, "__ifmissing")
+			declared := make(map[string]any)
+			if json.Unmarshal([]byte(a.Val), &declared) != nil {
+				for _, m := range quotedPairRe.FindAllStringSubmatch(a.Val, -1) {
+					declared[m[1]] = m[2]
+				}
+			}
+			for k, v := range declared {
+				if _, ok := sigs[k]; !ok || !ifMissing {
+					sigs[k] = v
+				}
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		readSignals(c, sigs)
+	}
+}
+
+var signalNameRe = regexp.MustCompile(`^[\w.]+$`)
+
+// assign applies the signal assignments of expr to sigs, such as
+// $count = 1, $name='Ada' or $open = !$open. Anything else is left to the
+// browser.
+func assign(sigs map[string]any, expr string) {
+	for _, stmt := range splitStatements(expr) {
+		name, val, ok := strings.Cut(stmt, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !strings.HasPrefix(name, "$") || strings.HasPrefix(val, "=") {
+			continue
+		}
+		name = name[1:]
+		if !signalNameRe.MatchString(name) {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		switch {
+		case strings.HasPrefix(val, "!$") && signalNameRe.MatchString(val[2:]):
+			sigs[name] = !truthy(sigs[val[2:]])
+		case len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'':
+			sigs[name] = strings.ReplaceAll(val[1:len(val)-1], `\'`, `'`)
+		default:
+			var v any
+			if json.Unmarshal([]byte(val), &v) == nil {
+				sigs[name] = v
+			}
+		}
+	}
+}
+
+// splitStatements splits expr at the commas and semicolons outside of
+// quotes and brackets.
+func splitStatements(expr string) []string {
+	var stmts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case (c == ',' || c == ';') && depth == 0:
+			stmts = append(stmts, expr[start:i])
+			start = i + 1
+		}
+	}
+	return append(stmts, expr[start:])
+}
+
+// truthy reports whether v is truthy in JavaScript.
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	}
+	return true
+}
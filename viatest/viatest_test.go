@@ -0,0 +1,241 @@
+package viatest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ryanhamamura/via"
+	"github.com/ryanhamamura/via/h"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func counterApp() *via.V {
+	v := via.New()
+	v.Page("/", func(c *via.Context) {
+		n := 0
+		step := c.Signal(1)
+		inc := c.Action(func() {
+			n += step.Int()
+			c.Sync()
+		})
+		reset := c.Action(func() {
+			n = 0
+			step.SetValue(1)
+			c.Sync()
+		})
+		c.View(func() h.H {
+			return h.Div(
+				h.P(h.ID("count"), h.Textf("%d", n)),
+				h.Input(h.ID("step"), step.Bind()),
+				h.Button(h.ID("inc"), h.Text("+"), inc.OnClick()),
+				h.Button(h.ID("inc3"), h.Text("+3"), inc.OnClick(via.WithSignalInt(step, 3))),
+				h.Button(h.ID("reset"), h.Text("Reset"), reset.OnClick()),
+			)
+		})
+	})
+	return v
+}
+
+func TestLoad(t *testing.T) {
+	p := Load(t, counterApp(), "/")
+
+	require.NotNil(t, p.Context())
+	assert.Equal(t, "0", p.Find("#count").Text())
+	csrf, ok := p.Signal("via-csrf")
+	assert.True(t, ok)
+	assert.NotEmpty(t, csrf)
+
+	patches := p.Patches()
+	require.NotEmpty(t, patches)
+	assert.Equal(t, PatchElements, patches[0].Type)
+}
+
+func TestPage_TriggerActions(t *testing.T) {
+	p := Load(t, counterApp(), "/")
+	stepID := p.Find("#step").Bind()
+	require.NotEmpty(t, stepID)
+
+	p.Click("#inc")
+	assert.Equal(t, "1", p.Find("#count").Text())
+
+	p.SetSignal(stepID, 5)
+	p.Click("#inc")
+	assert.Equal(t, "6", p.Find("#count").Text())
+
+	p.Click("#inc3")
+	assert.Equal(t, "9", p.Find("#count").Text())
+	step, _ := p.Signal(stepID)
+	assert.EqualValues(t, 3, step)
+}
+
+func TestPage_SignalPatches(t *testing.T) {
+	p := Load(t, counterApp(), "/")
+	stepID := p.Find("#step").Bind()
+	p.SetSignal(stepID, 7)
+	p.ClearPatches()
+
+	p.Click("#reset")
+
+	var sigs []Patch
+	for _, patch := range p.Patches() {
+		if patch.Type == PatchSignals {
+			sigs = append(sigs, patch)
+		}
+	}
+	require.Len(t, sigs, 1)
+	assert.Equal(t, "1", sigs[0].Signals[stepID])
+	step, _ := p.Signal(stepID)
+	assert.Equal(t, "1", step)
+}
+
+func TestPage_ScriptsAndRedirects(t *testing.T) {
+	v := via.New()
+	v.Page("/", func(c *via.Context) {
+		hello := c.Action(func() { c.ExecScript("console.log('hi')") })
+		filter := c.Action(func() { c.ReplaceURL("/?q=a b") })
+		leave := c.Action(func() { c.Redirect("/done") })
+		c.View(func() h.H {
+			return h.Div(
+				h.Button(h.ID("hello"), hello.OnClick()),
+				h.Button(h.ID("filter"), filter.OnClick()),
+				h.Button(h.ID("leave"), leave.OnClick()),
+			)
+		})
+	})
+	p := Load(t, v, "/")
+	p.ClearPatches()
+
+	p.Click("#hello")
+	p.Click("#filter")
+	p.Click("#leave")
+
+	assert.Equal(t, []Patch{
+		{Type: PatchScript, Script: "console.log('hi')"},
+		{Type: PatchReplaceURL, URL: "/?q=a b"},
+		{Type: PatchRedirect, URL: "/done"},
+	}, p.Patches())
+	assert.False(t, p.Find("script[data-effect]").Exists(), "scripts are not added to the DOM")
+}
+
+func TestPage_WaitForBroadcast(t *testing.T) {
+	v := via.New()
+	msg := "none"
+	v.Page("/", func(c *via.Context) {
+		c.View(func() h.H { return h.P(h.ID("msg"), h.Text(msg)) })
+	})
+	p := Load(t, v, "/")
+
+	v.Broadcast(nil, func(c *via.Context) {
+		msg = "hello"
+		c.Sync()
+	})
+	p.WaitFor(func() bool { return p.Find("#msg").Text() == "hello" })
+}
+
+func TestClient_SharesSession(t *testing.T) {
+	v := via.New()
+	v.Page("/", func(c *via.Context) {
+		visits, _ := via.SessionGet[int](c.Session(), "visits")
+		visits++
+		_ = via.SessionSet(c.Session(), "visits", visits) // fails during the panic check
+		name, _ := via.SessionGet[string](c.Session(), "name")
+		rename := c.Action(func() {
+			_ = via.SessionSet(c.Session(), "name", "Ada")
+			name = "Ada"
+			c.Sync()
+		})
+		c.View(func() h.H {
+			return h.Div(
+				h.P(h.ID("visits"), h.Textf("%d", visits)),
+				h.P(h.ID("name"), h.Text(name)),
+				h.Button(h.ID("rename"), rename.OnClick()),
+			)
+		})
+	})
+
+	cl := New(t, v)
+	p := cl.Load("/")
+	assert.Equal(t, "1", p.Find("#visits").Text())
+	p.Click("#rename")
+	assert.Equal(t, "Ada", p.Find("#name").Text())
+
+	p = cl.Load("/")
+	assert.Equal(t, "2", p.Find("#visits").Text(), "pages of a client share its session")
+	assert.Equal(t, "Ada", p.Find("#name").Text(), "actions write to the session")
+	assert.Equal(t, "1", Load(t, v, "/").Find("#visits").Text(), "new clients start a new session")
+}
+
+func TestPage_CloseDisposesContext(t *testing.T) {
+	v := counterApp()
+	p := Load(t, v, "/")
+	id := p.Context().ID()
+
+	p.Close()
+	p.WaitFor(func() bool { return len(v.Contexts(via.ByID(id))) == 0 })
+}
+
+func TestPatchElements(t *testing.T) {
+	cases := []struct {
+		name  string
+		patch Patch
+		want  string
+	}{
+		{"outer by id", Patch{Elements: `<p id="a">new</p>`, Mode: "outer"}, `<div id="box"><p id="a">new</p><p id="b">b</p></div>`},
+		{"remove by id", Patch{Elements: `<p id="b"></p>`, Mode: "remove"}, `<div id="box"><p id="a">a</p></div>`},
+		{"append", Patch{Elements: `<i>c</i>`, Selector: "#box", Mode: "append"}, `<div id="box"><p id="a">a</p><p id="b">b</p><i>c</i></div>`},
+		{"prepend", Patch{Elements: `<i>c</i>`, Selector: "#box", Mode: "prepend"}, `<div id="box"><i>c</i><p id="a">a</p><p id="b">b</p></div>`},
+		{"before", Patch{Elements: `<i>c</i>`, Selector: "#b", Mode: "before"}, `<div id="box"><p id="a">a</p><i>c</i><p id="b">b</p></div>`},
+		{"after", Patch{Elements: `<i>c</i>`, Selector: "#a", Mode: "after"}, `<div id="box"><p id="a">a</p><i>c</i><p id="b">b</p></div>`},
+		{"inner by selector", Patch{Elements: `x`, Selector: "p", Mode: "inner"}, `<div id="box"><p id="a">x</p><p id="b">x</p></div>`},
+		{"remove by selector", Patch{Selector: "#box p", Mode: "remove"}, `<div id="box"></div>`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(`<div id="box"><p id="a">a</p><p id="b">b</p></div>`))
+			require.NoError(t, err)
+			require.NoError(t, patchElements(doc, tc.patch))
+			assert.Equal(t, tc.want, renderBody(t, doc))
+		})
+	}
+
+	doc, _ := html.Parse(strings.NewReader(`<div id="box"></div>`))
+	assert.Error(t, patchElements(doc, Patch{Elements: `<p id="missing"></p>`, Mode: "outer"}))
+	assert.Error(t, patchElements(doc, Patch{Elements: `<p></p>`, Selector: "#missing", Mode: "append"}))
+}
+
+func renderBody(t *testing.T, doc *html.Node) string {
+	t.Helper()
+	body := byBody(doc)
+	require.NotNil(t, body)
+	var b strings.Builder
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		require.NoError(t, html.Render(&b, c))
+	}
+	return b.String()
+}
+
+func byBody(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && n.Data == "body" {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := byBody(c); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func TestAssign(t *testing.T) {
+	sigs := map[string]any{"open": false, "n": float64(1)}
+	assign(sigs, `$name='Ada, \'the\' first',$n = 2; $open = !$open, $n == 3 && @get('/_action/x')`)
+	assert.Equal(t, map[string]any{"name": "Ada, 'the' first", "n": float64(2), "open": true}, sigs)
+}
+
+func TestMergeSignals(t *testing.T) {
+	sigs := map[string]any{"a": "1", "form": map[string]any{"x": "1", "y": "2"}}
+	mergeSignals(sigs, map[string]any{"a": nil, "b": "2", "form": map[string]any{"y": nil, "z": "3"}})
+	assert.Equal(t, map[string]any{"b": "2", "form": map[string]any{"x": "1", "z": "3"}}, sigs)
+}